@@ -5,6 +5,8 @@ create database covenant_db;
 
 \c covenant_db
 
+create extension if not exists pg_trgm;
+
 create table users (
     id bigserial not null primary key,
     nickname varchar not null unique,
@@ -13,6 +15,9 @@ create table users (
     avatar varchar not null default varchar '/resources/avatars/default.jpg',
     role int not null default 0,
     access int not null default 0,
+    verified boolean not null default false,
+    verification_sent_at timestamp,
+    password_reset_sent_at timestamp,
     created_at timestamp not null default now(),
     updated_at timestamp not null default now()
 );
@@ -22,10 +27,19 @@ CREATE UNIQUE INDEX nickname_unique_index on users (LOWER(nickname));
 create table sessions (
     id bigserial not null primary key,
     user_id bigint not null references users(id) on delete cascade,
-    expires timestamp not null default now() + interval '24 hours',
-    data varchar not null
+    expires timestamp not null,
+    deadline timestamp not null,
+    remember boolean not null default false,
+    data varchar not null,
+    user_agent varchar not null default '',
+    ip varchar not null default '',
+    created_at timestamp not null default now(),
+    last_seen timestamp not null default now()
 );
 
+CREATE INDEX sessions_user_id_index on sessions (user_id);
+CREATE INDEX sessions_expires_index on sessions (expires);
+
 create table artists (
     id bigserial not null primary key,
     name varchar not null unique,
@@ -64,6 +78,10 @@ create table playlists (
     description varchar,
     owner_id bigint not null references users(id) on delete cascade,
     photo varchar not null default varchar '/resources/photos/playlists/default_playlist.jpg',
+    custom_photo boolean not null default false,
+    allow_duplicates boolean not null default false,
+    visibility int not null default 0,
+    link varchar not null unique default md5(random()::text || clock_timestamp()::text),
     created_at timestamp not null default now(),
     updated_at timestamp not null default now()
 );
@@ -72,8 +90,9 @@ create table playlist_track (
     id bigserial not null primary key,
     playlist_id bigint not null references playlists(id) on delete cascade,
     track_id bigint not null references tracks(id) on delete cascade,
+    position bigint not null default 0,
     created_at timestamp not null default now(),
-    unique (playlist_id, track_id)
+    constraint playlist_track_position_unique unique (playlist_id, position) deferrable initially deferred
 );
 
 create table subscriptions (
@@ -91,5 +110,189 @@ create table likes (
 	    track_id bigint not null references tracks(id) on delete cascade,
 	    created_at timestamp not null default now(),
 	    unique (user_id, track_id)
-)
+);
+
+create table plays (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    track_id bigint not null references tracks(id) on delete cascade,
+    played_at timestamp not null default now()
+);
+
+CREATE INDEX plays_user_played_at_index on plays (user_id, played_at DESC);
+
+create table track_charts (
+    period varchar not null,
+    position bigint not null,
+    track_id bigint not null references tracks(id) on delete cascade,
+    score bigint not null default 0,
+    refreshed_at timestamp not null default now(),
+    primary key (period, position)
+);
+
+CREATE INDEX likes_track_created_at_index on likes (track_id, created_at);
+CREATE INDEX favourites_track_created_at_index on favourites (track_id, created_at);
+CREATE INDEX plays_track_played_at_index on plays (track_id, played_at);
+
+CREATE INDEX likes_created_at_index on likes (created_at);
+CREATE INDEX favourites_created_at_index on favourites (created_at);
+CREATE INDEX plays_played_at_index on plays (played_at);
+
+create table playlist_collaborators (
+    id bigserial not null primary key,
+    playlist_id bigint not null references playlists(id) on delete cascade,
+    user_id bigint not null references users(id) on delete cascade,
+    accepted boolean not null default false,
+    created_at timestamp not null default now(),
+    unique (playlist_id, user_id)
+);
+
+create table playlist_likes (
+    id bigserial not null primary key,
+    playlist_id bigint not null references playlists(id) on delete cascade,
+    user_id bigint not null references users(id) on delete cascade,
+    created_at timestamp not null default now(),
+    unique (playlist_id, user_id)
+);
+
+CREATE INDEX tracks_name_trgm_index on tracks using gin (translate(lower(name), 'ё', 'е') gin_trgm_ops);
+CREATE INDEX albums_name_trgm_index on albums using gin (translate(lower(name), 'ё', 'е') gin_trgm_ops);
+CREATE INDEX artists_name_trgm_index on artists using gin (translate(lower(name), 'ё', 'е') gin_trgm_ops);
+CREATE INDEX users_nickname_trgm_index on users using gin (translate(lower(nickname), 'ё', 'е') gin_trgm_ops);
+
+CREATE INDEX tracks_name_fts_index on tracks using gin (to_tsvector('simple', translate(lower(name), 'ё', 'е')));
+CREATE INDEX albums_name_fts_index on albums using gin (to_tsvector('simple', translate(lower(name), 'ё', 'е')));
+CREATE INDEX artists_name_fts_index on artists using gin (to_tsvector('simple', translate(lower(name), 'ё', 'е')));
+
+CREATE INDEX track_charts_track_index on track_charts (period, track_id);
+
+CREATE INDEX tracks_name_prefix_index on tracks (translate(lower(name), 'ё', 'е') text_pattern_ops);
+CREATE INDEX albums_name_prefix_index on albums (translate(lower(name), 'ё', 'е') text_pattern_ops);
+CREATE INDEX artists_name_prefix_index on artists (translate(lower(name), 'ё', 'е') text_pattern_ops);
+CREATE INDEX users_nickname_prefix_index on users (translate(lower(nickname), 'ё', 'е') text_pattern_ops);
+
+create table search_history (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    query varchar not null,
+    searched_at timestamp not null default now(),
+    unique (user_id, query)
+);
+
+CREATE INDEX search_history_user_searched_at_index on search_history (user_id, searched_at DESC);
+
+create table search_trends (
+    query varchar not null,
+    day date not null default current_date,
+    count bigint not null default 1,
+    users bigint not null default 1,
+    primary key (query, day)
+);
+
+CREATE INDEX search_trends_day_index on search_trends (day);
+
+create table search_trend_salts (
+    day date not null primary key,
+    salt varchar not null default md5(random()::text || clock_timestamp()::text)
+);
+
+create table search_trend_users (
+    query varchar not null,
+    day date not null,
+    user_hash varchar not null,
+    primary key (query, day, user_hash)
+);
+
+CREATE INDEX playlists_name_trgm_index on playlists using gin (translate(lower(name), 'ё', 'е') gin_trgm_ops);
+CREATE INDEX playlists_description_trgm_index on playlists using gin (translate(lower(coalesce(description, '')), 'ё', 'е') gin_trgm_ops);
+
+CREATE INDEX playlists_name_fts_index on playlists using gin (to_tsvector('simple', translate(lower(name), 'ё', 'е')));
+CREATE INDEX playlists_description_fts_index on playlists using gin (to_tsvector('simple', translate(lower(coalesce(description, '')), 'ё', 'е')));
+
+create table follow_requests (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    requested_to bigint not null references users(id) on delete cascade,
+    created_at timestamp not null default now(),
+    unique (user_id, requested_to),
+    check (user_id != requested_to)
+);
+
+CREATE INDEX follow_requests_requested_to_index on follow_requests (requested_to, created_at DESC);
+
+create table user_settings (
+    user_id bigint not null primary key references users(id) on delete cascade,
+    show_favourites boolean not null default true,
+    show_history boolean not null default false,
+    follow_policy int not null default 0,
+    notify_followers boolean not null default true,
+    notify_collaborations boolean not null default true,
+    notify_likes boolean not null default true,
+    updated_at timestamp not null default now()
+);
+
+create table activities (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    type varchar not null,
+    track_id bigint references tracks(id) on delete cascade,
+    playlist_id bigint references playlists(id) on delete cascade,
+    target_id bigint references users(id) on delete cascade,
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX activities_user_id_index on activities (user_id, id DESC);
+
+create table notifications (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    type varchar not null,
+    actor_id bigint not null references users(id) on delete cascade,
+    playlist_id bigint references playlists(id) on delete cascade,
+    read boolean not null default false,
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX notifications_user_id_index on notifications (user_id, id DESC);
+CREATE INDEX notifications_unread_index on notifications (user_id) where not read;
+
+create table blocks (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    blocked_id bigint not null references users(id) on delete cascade,
+    created_at timestamp not null default now(),
+    unique (user_id, blocked_id),
+    check (user_id != blocked_id)
+);
+
+create table mutes (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    muted_id bigint not null references users(id) on delete cascade,
+    created_at timestamp not null default now(),
+    unique (user_id, muted_id),
+    check (user_id != muted_id)
+);
+
+create table password_resets (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    token_hash varchar not null unique,
+    expires timestamp not null,
+    used_at timestamp,
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX password_resets_user_id_index on password_resets (user_id);
+
+create table api_tokens (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    name varchar not null,
+    scope varchar not null,
+    token_hash varchar not null unique,
+    last_used timestamp,
+    created_at timestamp not null default now()
+);
 
+CREATE INDEX api_tokens_user_id_index on api_tokens (user_id);
@@ -17,5 +17,6 @@ require (
 	github.com/swaggo/echo-swagger v0.0.0-20190329130007-1219b460a043
 	github.com/swaggo/swag v1.6.3
 	golang.org/x/crypto v0.0.0-20191029031824-8986dd9e96cf
+	golang.org/x/net v0.0.0-20190611141213-3f473d35a33a
 	gopkg.in/go-playground/validator.v9 v9.30.0
 )
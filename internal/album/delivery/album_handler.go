@@ -42,13 +42,15 @@ func NewAlbumHandler(aUC album.Usecase,
 }
 
 func (ah *AlbumHandler) Configure(e *echo.Echo) {
-	e.DELETE("/api/v1/albums/:id", ah.DeleteAlbum(), ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
-	e.PUT("/api/v1/albums/:id", ah.UpdateAlbum(), ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
+	admin := ah.MManager.TokenScope(TOKEN_SCOPE_ADMIN)
+
+	e.DELETE("/api/v1/albums/:id", ah.DeleteAlbum(), admin, ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
+	e.PUT("/api/v1/albums/:id", ah.UpdateAlbum(), admin, ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
 	e.GET("/api/v1/albums", ah.GetAlbums())
 	e.GET("/api/v1/albums/:id", ah.GetSingleAlbum())
-	e.POST("/api/v1/albums/:id/tracks", ah.AddToAlbum(), ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
+	e.POST("/api/v1/albums/:id/tracks", ah.AddToAlbum(), admin, ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
 	e.GET("/api/v1/albums/:id/tracks", ah.GetTracksFromAlbum(), ah.MManager.CheckAuth)
-	e.PUT("/api/v1/albums/:id/photo", ah.UploadAlbumPhoto(), ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
+	e.PUT("/api/v1/albums/:id/photo", ah.UploadAlbumPhoto(), admin, ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
 }
 
 func (ah *AlbumHandler) UploadAlbumPhoto() echo.HandlerFunc {
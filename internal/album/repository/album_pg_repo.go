@@ -5,7 +5,6 @@ import (
 	"2019_2_Covenant/internal/models"
 	. "2019_2_Covenant/tools/vars"
 	"database/sql"
-	"strings"
 )
 
 type AlbumRepository struct {
@@ -18,42 +17,6 @@ func NewAlbumRepository(db *sql.DB) album.Repository {
 	}
 }
 
-func (ar *AlbumRepository) FindLike(name string, count uint64) ([]*models.Album, error) {
-	var albums []*models.Album
-
-	rows, err := ar.db.Query("select Al.id, Al.artist_id, Al.name, Al.photo, Al.year, Ar.name, Ar.id " +
-		"from albums Al join artists Ar on Al.artist_id = Ar.id where lower(Al.name) like '%' || $1 || '%' " +
-		"OR lower(Ar.name) like '%' || $1 || '%' limit $2",
-		strings.ToLower(name),
-		count)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-
-		return nil, err
-	}
-
-	defer rows.Close()
-
-	for rows.Next() {
-		a := &models.Album{}
-
-		if err := rows.Scan(&a.ID, &a.ArtistID, &a.Name, &a.Photo, &a.Year, &a.Artist, &a.ArtistID); err != nil {
-			return nil, err
-		}
-
-		albums = append(albums, a)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return albums, nil
-}
-
 func (ar *AlbumRepository) DeleteByID(id uint64) error {
 	if err := ar.db.QueryRow("DELETE FROM albums WHERE id = $1 RETURNING id",
 		id,
@@ -93,7 +56,7 @@ func (ar *AlbumRepository) Fetch(count uint64, offset uint64) ([]*models.Album,
 		return nil, total, err
 	}
 
-	rows, err := ar.db.Query("SELECT Al.id, Al.artist_id, Al.name, Al.photo, Al.year, Ar.name, Ar.id " +
+	rows, err := ar.db.Query("SELECT Al.id, Al.artist_id, Al.name, Al.photo, Al.year, Ar.name, Ar.id "+
 		"FROM albums Al JOIN artists Ar ON Al.artist_id = Ar.id ORDER BY Al.name LIMIT $1 OFFSET $2",
 		count,
 		offset,
@@ -134,7 +97,7 @@ func (ar *AlbumRepository) GetByID(id uint64) (*models.Album, uint64, error) {
 	a := &models.Album{}
 	var amountOfTracks uint64
 
-	if err := ar.db.QueryRow("SELECT Al.id, Al.artist_id, Al.name, Al.photo, Al.year, Ar.name, Ar.id " +
+	if err := ar.db.QueryRow("SELECT Al.id, Al.artist_id, Al.name, Al.photo, Al.year, Ar.name, Ar.id "+
 		"FROM albums Al JOIN artists Ar ON Al.artist_id = Ar.id WHERE Al.id = $1",
 		id,
 	).Scan(
@@ -190,12 +153,12 @@ func (ar *AlbumRepository) GetTracksFrom(albumID uint64, authID uint64) ([]*mode
 	var tracks []*models.Track
 
 	rows, err := ar.db.Query(
-		"select T.id, T.name, T.duration, T.path, Ar.name, Al.name, Ar.id, " +
-			"T.id in (select track_id from favourites where user_id = $1) as favourite, " +
-			"T.id in (select track_id from likes where user_id = $1) AS liked from tracks T " +
-			"join albums Al ON T.album_id=Al.id " +
+		"select T.id, T.name, T.duration, T.path, Ar.name, Al.name, Ar.id, "+
+			"T.id in (select track_id from favourites where user_id = $1) as favourite, "+
+			"T.id in (select track_id from likes where user_id = $1) AS liked from tracks T "+
+			"join albums Al ON T.album_id=Al.id "+
 			"join artists Ar ON Al.artist_id=Ar.id where Al.id = $2;",
-			authID, albumID)
+		authID, albumID)
 
 	if err != nil {
 		return nil, err
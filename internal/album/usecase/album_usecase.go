@@ -15,22 +15,6 @@ func NewAlbumUsecase(repo album.Repository) album.Usecase {
 	}
 }
 
-func (aUC *AlbumUsecase) FindLike(name string, count uint64) ([]*models.Album, error) {
-	albums, err := aUC.albumRepo.FindLike(name, count)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if albums == nil {
-		albums = []*models.Album{}
-	}
-
-	for _, a := range albums { a.Year = a.Year[:4] }
-
-	return albums, nil
-}
-
 func (aUC *AlbumUsecase) DeleteByID(id uint64) error {
 	if err := aUC.albumRepo.DeleteByID(id); err != nil {
 		return err
@@ -58,7 +42,9 @@ func (aUC *AlbumUsecase) Fetch(count uint64, offset uint64) ([]*models.Album, ui
 		albums = []*models.Album{}
 	}
 
-	for _, a := range albums { a.Year = a.Year[:4] }
+	for _, a := range albums {
+		a.Year = a.Year[:4]
+	}
 
 	return albums, total, nil
 }
@@ -4,11 +4,68 @@ type Config struct {
 	Address  string `toml:"server_addr"`
 	Port     string `toml:"server_port"`
 	LogLevel string `toml:"log_level"`
+
+	ChartsRefreshInterval string `toml:"charts_refresh_interval"`
+	TrendsSweepInterval   string `toml:"trends_sweep_interval"`
+
+	// Sessions expire after SessionIdleTimeout without use or SessionAbsoluteTimeout
+	// after login, whichever comes first; "remember me" sessions use the Remember* pair
+	SessionIdleTimeout             string `toml:"session_idle_timeout"`
+	SessionAbsoluteTimeout         string `toml:"session_absolute_timeout"`
+	SessionRememberIdleTimeout     string `toml:"session_remember_idle_timeout"`
+	SessionRememberAbsoluteTimeout string `toml:"session_remember_absolute_timeout"`
+	SessionSweepInterval           string `toml:"session_sweep_interval"`
+
+	// Mailer is either "smtp" or "log", the latter writes letters to
+	// MailLog file (stdout if empty) instead of sending them
+	Mailer       string `toml:"mailer"`
+	MailFrom     string `toml:"mail_from"`
+	MailLog      string `toml:"mail_log"`
+	SMTPHost     string `toml:"smtp_host"`
+	SMTPPort     string `toml:"smtp_port"`
+	SMTPUser     string `toml:"smtp_user"`
+	SMTPPassword string `toml:"smtp_password"`
+
+	PasswordResetURL            string `toml:"password_reset_url"`
+	PasswordResetTTL            string `toml:"password_reset_ttl"`
+	PasswordResetResendInterval string `toml:"password_reset_resend_interval"`
+
+	// Key signing verification links, VERIFICATION_SECRET env variable overrides it
+	VerificationSecret         string `toml:"verification_secret"`
+	VerificationURL            string `toml:"verification_url"`
+	VerificationTTL            string `toml:"verification_ttl"`
+	VerificationResendInterval string `toml:"verification_resend_interval"`
+
+	// Actions forbidden to users until they verify their email, see RESTRICT_* constants
+	RestrictUnverified []string `toml:"restrict_unverified"`
 }
 
 func NewConfig() *Config {
 	return &Config{
 		Address: "127.0.0.1",
 		Port:    "3000",
+
+		ChartsRefreshInterval: "10m",
+		TrendsSweepInterval:   "1h",
+
+		SessionIdleTimeout:             "24h",
+		SessionAbsoluteTimeout:         "168h",
+		SessionRememberIdleTimeout:     "720h",
+		SessionRememberAbsoluteTimeout: "2160h",
+		SessionSweepInterval:           "1h",
+
+		Mailer:   "log",
+		MailFrom: "Covenant <noreply@localhost>",
+		SMTPPort: "587",
+
+		PasswordResetURL:            "http://localhost:3000/password/reset",
+		PasswordResetTTL:            "1h",
+		PasswordResetResendInterval: "5m",
+
+		VerificationURL:            "http://localhost:3000/verification",
+		VerificationTTL:            "72h",
+		VerificationResendInterval: "5m",
+
+		RestrictUnverified: []string{"public_playlists"},
 	}
 }
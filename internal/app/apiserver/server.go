@@ -6,27 +6,50 @@ import (
 	"2019_2_Covenant/internal/app/storage"
 	_artistDelivery "2019_2_Covenant/internal/artist/delivery"
 	_artistUsecase "2019_2_Covenant/internal/artist/usecase"
+	_blocksDelivery "2019_2_Covenant/internal/blocks/delivery"
+	_blocksUsecase "2019_2_Covenant/internal/blocks/usecase"
+	_feedDelivery "2019_2_Covenant/internal/feed/delivery"
+	_feedUsecase "2019_2_Covenant/internal/feed/usecase"
+	_historyDelivery "2019_2_Covenant/internal/history/delivery"
+	_historyUsecase "2019_2_Covenant/internal/history/usecase"
 	_likesDelivery "2019_2_Covenant/internal/likes/delivery"
 	_likesUsecase "2019_2_Covenant/internal/likes/usecase"
 	"2019_2_Covenant/internal/middlewares"
+	_notificationsDelivery "2019_2_Covenant/internal/notifications/delivery"
+	_notificationsUsecase "2019_2_Covenant/internal/notifications/usecase"
+	_passwordDelivery "2019_2_Covenant/internal/password/delivery"
+	_passwordUsecase "2019_2_Covenant/internal/password/usecase"
 	_playlistDelivery "2019_2_Covenant/internal/playlist/delivery"
 	_playlistUsecase "2019_2_Covenant/internal/playlist/usecase"
+	"2019_2_Covenant/internal/realtime"
+	_realtimeDelivery "2019_2_Covenant/internal/realtime/delivery"
+	"2019_2_Covenant/internal/search"
 	_searchDelivery "2019_2_Covenant/internal/search/delivery"
 	_searchUsecase "2019_2_Covenant/internal/search/usecase"
+	"2019_2_Covenant/internal/session"
 	_sessionDelivery "2019_2_Covenant/internal/session/delivery"
 	_sessionUsecase "2019_2_Covenant/internal/session/usecase"
 	_subscriptionDelivery "2019_2_Covenant/internal/subscriptions/delivery"
 	_subscriptionUsecase "2019_2_Covenant/internal/subscriptions/usecase"
+	_tokensDelivery "2019_2_Covenant/internal/tokens/delivery"
+	_tokensUsecase "2019_2_Covenant/internal/tokens/usecase"
+	"2019_2_Covenant/internal/track"
 	_trackDelivery "2019_2_Covenant/internal/track/delivery"
 	_trackUsecase "2019_2_Covenant/internal/track/usecase"
 	_userDelivery "2019_2_Covenant/internal/user/delivery"
 	_userUsecase "2019_2_Covenant/internal/user/usecase"
+	_verificationDelivery "2019_2_Covenant/internal/verification/delivery"
+	_verificationUsecase "2019_2_Covenant/internal/verification/usecase"
 	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/mailer"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 	echoSwagger "github.com/swaggo/echo-swagger"
 	"net/http"
+	"os"
+	"sync"
+	"time"
 )
 
 type APIServer struct {
@@ -34,6 +57,10 @@ type APIServer struct {
 	router  *echo.Echo
 	storage storage.Storage
 	logger  *logger.LogrusLogger
+	mailer  mailer.Mailer
+	mailLog *os.File
+	done    chan struct{}
+	stop    sync.Once
 }
 
 func NewAPIServer(conf *Config, st storage.Storage) *APIServer {
@@ -42,6 +69,7 @@ func NewAPIServer(conf *Config, st storage.Storage) *APIServer {
 		router:  echo.New(),
 		storage: st,
 		logger:  logger.NewLogrusLogger(),
+		done:    make(chan struct{}),
 	}
 }
 
@@ -56,45 +84,114 @@ func (api *APIServer) Start() error {
 		return err
 	}
 
-	api.configureRouter()
+	if err := api.configureMailer(); err != nil {
+		return err
+	}
+
+	if err := api.configureRouter(); err != nil {
+		return err
+	}
 
 	return api.router.Start(fmt.Sprintf("%s:%s", api.conf.Address, api.conf.Port))
 }
 
-func (api *APIServer) configureRouter() {
+func (api *APIServer) configureRouter() error {
 	api.router.GET("/docs/*", echoSwagger.WrapHandler)
 
+	// Only pictures are served statically, tracks are reachable through the stream route alone
 	fs := http.FileServer(http.Dir("resources/"))
-	api.router.GET("/resources/*", echo.WrapHandler(http.StripPrefix("/resources/", fs)))
+	api.router.GET("/resources/avatars/*", echo.WrapHandler(http.StripPrefix("/resources/", fs)))
+	api.router.GET("/resources/photos/*", echo.WrapHandler(http.StripPrefix("/resources/", fs)))
+
+	// Activities and notifications are pushed to connected clients as they are stored
+	hub := realtime.NewHub(api.logger)
+	feedRepo := realtime.NewFeedRepository(api.storage.Feed(), hub)
+	notificationRepo := realtime.NewNotificationsRepository(api.storage.Notification(), hub)
+
+	timeouts, err := api.sessionTimeouts()
 
-	userUsecase := _userUsecase.NewUserUsecase(api.storage.User())
-	sessionUsecase := _sessionUsecase.NewSessionUsecase(api.storage.Session())
-	trackUsecase := _trackUsecase.NewTrackUsecase(api.storage.Track())
-	playlistUsecase := _playlistUsecase.NewPlaylistUsecase(api.storage.Playlist())
-	searchUsecase := _searchUsecase.NewSearchUsecase(api.storage.Track(), api.storage.Album(), api.storage.Artist())
+	if err != nil {
+		return err
+	}
+
+	userUsecase := _userUsecase.NewUserUsecase(api.storage.User(), feedRepo, notificationRepo, api.logger)
+	sessionUsecase := _sessionUsecase.NewSessionUsecase(api.storage.Session(), timeouts, hub)
+	trackUsecase := _trackUsecase.NewTrackUsecase(api.storage.Track(), feedRepo, api.logger)
+	playlistUsecase := _playlistUsecase.NewPlaylistUsecase(api.storage.Playlist(), feedRepo, notificationRepo, hub,
+		api.logger)
+	searchUsecase := _searchUsecase.NewSearchUsecase(api.storage.Search(), api.storage.Playlist(), api.logger)
 	artistUsecase := _artistUsecase.NewArtistUsecase(api.storage.Artist())
 	albumUsecase := _albumUsecase.NewAlbumUsecase(api.storage.Album())
-	subscriptionUsecase := _subscriptionUsecase.NewSubscriptionUsecase(api.storage.Subscription())
-	likesUsecase := _likesUsecase.NewLikesUsecase(api.storage.Like())
+	subscriptionUsecase := _subscriptionUsecase.NewSubscriptionUsecase(api.storage.Subscription(), api.storage.User(),
+		feedRepo, notificationRepo, api.logger)
+	likesUsecase := _likesUsecase.NewLikesUsecase(api.storage.Like(), feedRepo, api.logger)
+	historyUsecase := _historyUsecase.NewHistoryUsecase(api.storage.History())
+	feedUsecase := _feedUsecase.NewFeedUsecase(api.storage.Feed())
+	notificationsUsecase := _notificationsUsecase.NewNotificationsUsecase(api.storage.Notification())
+	blocksUsecase := _blocksUsecase.NewBlocksUsecase(api.storage.Blocks())
+	tokensUsecase := _tokensUsecase.NewTokensUsecase(api.storage.Tokens(), api.logger)
+
+	resetTTL, err := time.ParseDuration(api.conf.PasswordResetTTL)
+
+	if err != nil {
+		return fmt.Errorf("bad password reset ttl: %v", err)
+	}
+
+	resetResendInterval, err := time.ParseDuration(api.conf.PasswordResetResendInterval)
+
+	if err != nil {
+		return fmt.Errorf("bad password reset resend interval: %v", err)
+	}
+
+	passwordUsecase := _passwordUsecase.NewPasswordUsecase(api.storage.Password(), api.storage.User(), api.mailer,
+		hub, api.conf.PasswordResetURL, resetTTL, resetResendInterval, api.logger)
+
+	verificationTTL, err := time.ParseDuration(api.conf.VerificationTTL)
+
+	if err != nil {
+		return fmt.Errorf("bad verification ttl: %v", err)
+	}
+
+	resendInterval, err := time.ParseDuration(api.conf.VerificationResendInterval)
 
-	middlewareManager := middlewares.NewMiddlewareManager(userUsecase, sessionUsecase, api.logger)
+	if err != nil {
+		return fmt.Errorf("bad verification resend interval: %v", err)
+	}
+
+	secret := api.conf.VerificationSecret
+
+	if env := os.Getenv("VERIFICATION_SECRET"); env != "" {
+		secret = env
+	}
+
+	// Anyone could forge verification links signed with a public key
+	if secret == "" || secret == "Covenant" {
+		return fmt.Errorf("verification secret is not set")
+	}
+
+	verificationUsecase := _verificationUsecase.NewVerificationUsecase(api.storage.Verification(), api.storage.User(),
+		api.mailer, secret, api.conf.VerificationURL, verificationTTL, resendInterval, api.logger)
+
+	middlewareManager := middlewares.NewMiddlewareManager(userUsecase, sessionUsecase, tokensUsecase,
+		api.conf.RestrictUnverified, api.logger)
 	api.router.Use(middlewareManager.AccessLogMiddleware)
 	api.router.Use(middlewareManager.PanicRecovering)
 	api.router.Use(middlewareManager.CORSMiddleware)
 
-	userHandler := _userDelivery.NewUserHandler(userUsecase, sessionUsecase, playlistUsecase, middlewareManager, api.logger)
+	userHandler := _userDelivery.NewUserHandler(userUsecase, sessionUsecase, playlistUsecase, trackUsecase, verificationUsecase,
+		middlewareManager, api.logger)
 	userHandler.Configure(api.router)
 
-	trackHandler := _trackDelivery.NewTrackHandler(trackUsecase, middlewareManager, api.logger)
+	trackHandler := _trackDelivery.NewTrackHandler(trackUsecase, historyUsecase, middlewareManager, api.logger)
 	trackHandler.Configure(api.router)
 
 	sessionHandler := _sessionDelivery.NewSessionHandler(sessionUsecase, userUsecase, middlewareManager, api.logger)
 	sessionHandler.Configure(api.router)
 
-	playlistHandler := _playlistDelivery.NewPlaylistHandler(playlistUsecase, middlewareManager, api.logger)
+	playlistHandler := _playlistDelivery.NewPlaylistHandler(playlistUsecase, trackUsecase, middlewareManager, api.logger)
 	playlistHandler.Configure(api.router)
 
-	searchHandler := _searchDelivery.NewSearchHandler(searchUsecase, userUsecase, middlewareManager, api.logger)
+	searchHandler := _searchDelivery.NewSearchHandler(searchUsecase, middlewareManager, api.logger)
 	searchHandler.Configure(api.router)
 
 	artistHandler := _artistDelivery.NewArtistHandler(artistUsecase, middlewareManager, api.logger)
@@ -108,6 +205,156 @@ func (api *APIServer) configureRouter() {
 
 	likesHandler := _likesDelivery.NewLikesHandler(likesUsecase, userUsecase, middlewareManager, api.logger)
 	likesHandler.Configure(api.router)
+
+	historyHandler := _historyDelivery.NewHistoryHandler(historyUsecase, userUsecase, middlewareManager, api.logger)
+	historyHandler.Configure(api.router)
+
+	feedHandler := _feedDelivery.NewFeedHandler(feedUsecase, middlewareManager, api.logger)
+	feedHandler.Configure(api.router)
+
+	notificationsHandler := _notificationsDelivery.NewNotificationsHandler(notificationsUsecase, middlewareManager, api.logger)
+	notificationsHandler.Configure(api.router)
+
+	wsHandler := _realtimeDelivery.NewWSHandler(hub, middlewareManager, api.logger)
+	wsHandler.Configure(api.router)
+
+	blocksHandler := _blocksDelivery.NewBlocksHandler(blocksUsecase, middlewareManager, api.logger)
+	blocksHandler.Configure(api.router)
+
+	passwordHandler := _passwordDelivery.NewPasswordHandler(passwordUsecase, middlewareManager, api.logger)
+	passwordHandler.Configure(api.router)
+
+	verificationHandler := _verificationDelivery.NewVerificationHandler(verificationUsecase, middlewareManager, api.logger)
+	verificationHandler.Configure(api.router)
+
+	tokensHandler := _tokensDelivery.NewTokensHandler(tokensUsecase, middlewareManager, api.logger)
+	tokensHandler.Configure(api.router)
+
+	sweepInterval, err := time.ParseDuration(api.conf.SessionSweepInterval)
+
+	if err != nil {
+		return fmt.Errorf("bad session sweep interval: %v", err)
+	}
+
+	if sweepInterval <= 0 {
+		return fmt.Errorf("bad session sweep interval: must be positive")
+	}
+
+	chartsInterval, err := time.ParseDuration(api.conf.ChartsRefreshInterval)
+
+	if err != nil {
+		return fmt.Errorf("bad charts refresh interval: %v", err)
+	}
+
+	if chartsInterval <= 0 {
+		return fmt.Errorf("bad charts refresh interval: must be positive")
+	}
+
+	trendsInterval, err := time.ParseDuration(api.conf.TrendsSweepInterval)
+
+	if err != nil {
+		return fmt.Errorf("bad trends sweep interval: %v", err)
+	}
+
+	if trendsInterval <= 0 {
+		return fmt.Errorf("bad trends sweep interval: must be positive")
+	}
+
+	go api.refreshCharts(trackUsecase, chartsInterval)
+	go api.sweepSessions(sessionUsecase, sweepInterval)
+	go api.sweepTrends(searchUsecase, trendsInterval)
+
+	return nil
+}
+
+func (api *APIServer) sessionTimeouts() (session.Timeouts, error) {
+	var timeouts session.Timeouts
+
+	for _, t := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"session idle timeout", api.conf.SessionIdleTimeout, &timeouts.Idle},
+		{"session absolute timeout", api.conf.SessionAbsoluteTimeout, &timeouts.Absolute},
+		{"session remember idle timeout", api.conf.SessionRememberIdleTimeout, &timeouts.RememberIdle},
+		{"session remember absolute timeout", api.conf.SessionRememberAbsoluteTimeout, &timeouts.RememberAbsolute},
+	} {
+		d, err := time.ParseDuration(t.value)
+
+		if err != nil {
+			return timeouts, fmt.Errorf("bad %s: %v", t.name, err)
+		}
+
+		if d <= 0 {
+			return timeouts, fmt.Errorf("bad %s: must be positive", t.name)
+		}
+
+		*t.dst = d
+	}
+
+	if timeouts.Idle > timeouts.Absolute {
+		return timeouts, fmt.Errorf("session idle timeout exceeds the absolute one")
+	}
+
+	if timeouts.RememberIdle > timeouts.RememberAbsolute {
+		return timeouts, fmt.Errorf("session remember idle timeout exceeds the absolute one")
+	}
+
+	return timeouts, nil
+}
+
+func (api *APIServer) sweepSessions(sUC session.Usecase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if deleted, err := sUC.DeleteExpired(); err != nil {
+			api.logger.L.Error("session sweeping error: ", err)
+		} else if deleted > 0 {
+			api.logger.L.Info("expired sessions swept: ", deleted)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-api.done:
+			return
+		}
+	}
+}
+
+func (api *APIServer) refreshCharts(tUC track.Usecase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := tUC.RefreshCharts(); err != nil {
+			api.logger.L.Error("charts refreshing error: ", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-api.done:
+			return
+		}
+	}
+}
+
+func (api *APIServer) sweepTrends(sUC search.Usecase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := sUC.PruneTrends(); err != nil {
+			api.logger.L.Error("trends sweeping error: ", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-api.done:
+			return
+		}
+	}
 }
 
 func (api *APIServer) configureStorage() error {
@@ -118,6 +365,33 @@ func (api *APIServer) configureStorage() error {
 	return nil
 }
 
+func (api *APIServer) configureMailer() error {
+	switch api.conf.Mailer {
+	case "smtp":
+		api.mailer = mailer.NewSMTPMailer(api.conf.SMTPHost, api.conf.SMTPPort, api.conf.SMTPUser,
+			api.conf.SMTPPassword, api.conf.MailFrom)
+	case "log":
+		w := os.Stdout
+
+		if api.conf.MailLog != "" {
+			f, err := os.OpenFile(api.conf.MailLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+			if err != nil {
+				return err
+			}
+
+			api.mailLog = f
+			w = f
+		}
+
+		api.mailer = mailer.NewLogMailer(w, api.conf.MailFrom)
+	default:
+		return fmt.Errorf("unknown mailer %q", api.conf.Mailer)
+	}
+
+	return nil
+}
+
 func (api *APIServer) configureLogger() error {
 	level, err := logrus.ParseLevel(api.conf.LogLevel)
 
@@ -130,6 +404,14 @@ func (api *APIServer) configureLogger() error {
 	return nil
 }
 
+// Stop releases the server's resources, calls after the first one do nothing
 func (api *APIServer) Stop() {
-	api.storage.Close()
+	api.stop.Do(func() {
+		close(api.done)
+		api.storage.Close()
+
+		if api.mailLog != nil {
+			api.mailLog.Close()
+		}
+	})
 }
@@ -5,18 +5,34 @@ import (
 	_albumRepo "2019_2_Covenant/internal/album/repository"
 	"2019_2_Covenant/internal/artist"
 	_artistRepo "2019_2_Covenant/internal/artist/repository"
+	"2019_2_Covenant/internal/blocks"
+	_blocksRepo "2019_2_Covenant/internal/blocks/repository"
+	"2019_2_Covenant/internal/feed"
+	_feedRepo "2019_2_Covenant/internal/feed/repository"
+	"2019_2_Covenant/internal/history"
+	_historyRepo "2019_2_Covenant/internal/history/repository"
 	"2019_2_Covenant/internal/likes"
 	_likesRepo "2019_2_Covenant/internal/likes/repository"
+	"2019_2_Covenant/internal/notifications"
+	_notificationsRepo "2019_2_Covenant/internal/notifications/repository"
+	"2019_2_Covenant/internal/password"
+	_passwordRepo "2019_2_Covenant/internal/password/repository"
 	"2019_2_Covenant/internal/playlist"
 	_playlistRepo "2019_2_Covenant/internal/playlist/repository"
+	"2019_2_Covenant/internal/search"
+	_searchRepo "2019_2_Covenant/internal/search/repository"
 	"2019_2_Covenant/internal/session"
 	_sessRepo "2019_2_Covenant/internal/session/repository"
 	"2019_2_Covenant/internal/subscriptions"
 	_subscriptionRepo "2019_2_Covenant/internal/subscriptions/repository"
+	"2019_2_Covenant/internal/tokens"
+	_tokensRepo "2019_2_Covenant/internal/tokens/repository"
 	"2019_2_Covenant/internal/track"
 	_trackRepo "2019_2_Covenant/internal/track/repository"
 	"2019_2_Covenant/internal/user"
 	_userRepo "2019_2_Covenant/internal/user/repository"
+	"2019_2_Covenant/internal/verification"
+	_verificationRepo "2019_2_Covenant/internal/verification/repository"
 	"database/sql"
 	_ "github.com/lib/pq"
 )
@@ -36,6 +52,14 @@ type PGStorage struct {
 	artistRepo       artist.Repository
 	subscriptionRepo subscriptions.Repository
 	likesRepo        likes.Repository
+	historyRepo      history.Repository
+	searchRepo       search.Repository
+	feedRepo         feed.Repository
+	notificationRepo notifications.Repository
+	blocksRepo       blocks.Repository
+	passwordRepo     password.Repository
+	verificationRepo verification.Repository
+	tokensRepo       tokens.Repository
 }
 
 func NewPGStorage(conf *Config) Storage {
@@ -145,3 +169,83 @@ func (s *PGStorage) Like() likes.Repository {
 
 	return s.likesRepo
 }
+
+func (s *PGStorage) History() history.Repository {
+	if s.historyRepo != nil {
+		return s.historyRepo
+	}
+
+	s.historyRepo = _historyRepo.NewHistoryRepository(s.db)
+
+	return s.historyRepo
+}
+
+func (s *PGStorage) Search() search.Repository {
+	if s.searchRepo != nil {
+		return s.searchRepo
+	}
+
+	s.searchRepo = _searchRepo.NewSearchRepository(s.db)
+
+	return s.searchRepo
+}
+
+func (s *PGStorage) Feed() feed.Repository {
+	if s.feedRepo != nil {
+		return s.feedRepo
+	}
+
+	s.feedRepo = _feedRepo.NewFeedRepository(s.db)
+
+	return s.feedRepo
+}
+
+func (s *PGStorage) Notification() notifications.Repository {
+	if s.notificationRepo != nil {
+		return s.notificationRepo
+	}
+
+	s.notificationRepo = _notificationsRepo.NewNotificationsRepository(s.db)
+
+	return s.notificationRepo
+}
+
+func (s *PGStorage) Blocks() blocks.Repository {
+	if s.blocksRepo != nil {
+		return s.blocksRepo
+	}
+
+	s.blocksRepo = _blocksRepo.NewBlocksRepository(s.db)
+
+	return s.blocksRepo
+}
+
+func (s *PGStorage) Password() password.Repository {
+	if s.passwordRepo != nil {
+		return s.passwordRepo
+	}
+
+	s.passwordRepo = _passwordRepo.NewPasswordRepository(s.db)
+
+	return s.passwordRepo
+}
+
+func (s *PGStorage) Verification() verification.Repository {
+	if s.verificationRepo != nil {
+		return s.verificationRepo
+	}
+
+	s.verificationRepo = _verificationRepo.NewVerificationRepository(s.db)
+
+	return s.verificationRepo
+}
+
+func (s *PGStorage) Tokens() tokens.Repository {
+	if s.tokensRepo != nil {
+		return s.tokensRepo
+	}
+
+	s.tokensRepo = _tokensRepo.NewTokensRepository(s.db)
+
+	return s.tokensRepo
+}
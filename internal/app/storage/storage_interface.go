@@ -3,12 +3,20 @@ package storage
 import (
 	"2019_2_Covenant/internal/album"
 	"2019_2_Covenant/internal/artist"
+	"2019_2_Covenant/internal/blocks"
+	"2019_2_Covenant/internal/feed"
+	"2019_2_Covenant/internal/history"
 	"2019_2_Covenant/internal/likes"
-	"2019_2_Covenant/internal/subscriptions"
+	"2019_2_Covenant/internal/notifications"
+	"2019_2_Covenant/internal/password"
 	"2019_2_Covenant/internal/playlist"
+	"2019_2_Covenant/internal/search"
 	"2019_2_Covenant/internal/session"
+	"2019_2_Covenant/internal/subscriptions"
+	"2019_2_Covenant/internal/tokens"
 	"2019_2_Covenant/internal/track"
 	"2019_2_Covenant/internal/user"
+	"2019_2_Covenant/internal/verification"
 )
 
 type Storage interface {
@@ -22,4 +30,12 @@ type Storage interface {
 	Artist() artist.Repository
 	Subscription() subscriptions.Repository
 	Like() likes.Repository
+	History() history.Repository
+	Search() search.Repository
+	Feed() feed.Repository
+	Notification() notifications.Repository
+	Blocks() blocks.Repository
+	Password() password.Repository
+	Verification() verification.Repository
+	Tokens() tokens.Repository
 }
@@ -41,13 +41,15 @@ func NewArtistHandler(aUC artist.Usecase,
 }
 
 func (ah *ArtistHandler) Configure(e *echo.Echo) {
-	e.POST("/api/v1/artists", ah.CreateArtist(), ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
-	e.DELETE("/api/v1/artists/:id", ah.DeleteArtist(), ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
-	e.PUT("/api/v1/artists/:id", ah.UpdateArtist(), ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
-	e.PUT("/api/v1/artists/:id/photo", ah.UploadArtistPhoto(), ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
+	admin := ah.MManager.TokenScope(TOKEN_SCOPE_ADMIN)
+
+	e.POST("/api/v1/artists", ah.CreateArtist(), admin, ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
+	e.DELETE("/api/v1/artists/:id", ah.DeleteArtist(), admin, ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
+	e.PUT("/api/v1/artists/:id", ah.UpdateArtist(), admin, ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
+	e.PUT("/api/v1/artists/:id/photo", ah.UploadArtistPhoto(), admin, ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
 	e.GET("/api/v1/artists", ah.GetArtists())
 	e.GET("/api/v1/artists/:id", ah.GetSingleArtist())
-	e.POST("/api/v1/artists/:id/albums", ah.CreateAlbum(), ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
+	e.POST("/api/v1/artists/:id/albums", ah.CreateAlbum(), admin, ah.MManager.CheckAuthStrictly, ah.MManager.CheckAdmin)
 	e.GET("/api/v1/artists/:id/albums", ah.GetArtistAlbums())
 	e.GET("/api/v1/artists/:id/tracks", ah.GetArtistTracks(), ah.MManager.CheckAuth)
 }
@@ -94,7 +96,7 @@ func (ah *ArtistHandler) GetArtistTracks() echo.HandlerFunc {
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
 				"tracks": tracks,
-				"total": total,
+				"total":  total,
 			},
 		})
 	}
@@ -137,7 +139,7 @@ func (ah *ArtistHandler) GetArtistAlbums() echo.HandlerFunc {
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
 				"albums": albums,
-				"total": total,
+				"total":  total,
 			},
 		})
 	}
@@ -208,8 +210,8 @@ func (ah *ArtistHandler) UploadArtistPhoto() echo.HandlerFunc {
 
 func (ah *ArtistHandler) CreateAlbum() echo.HandlerFunc {
 	type Request struct {
-		Name     string `json:"name" validate:"required"`
-		Year     string `json:"year" validate:"required"`
+		Name string `json:"name" validate:"required"`
+		Year string `json:"year" validate:"required"`
 	}
 
 	correctData := func(req interface{}) bool {
@@ -388,7 +390,7 @@ func (ah *ArtistHandler) GetArtists() echo.HandlerFunc {
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
 				"artists": artists,
-				"total": total,
+				"total":   total,
 			},
 		})
 	}
@@ -416,7 +418,7 @@ func (ah *ArtistHandler) GetSingleArtist() echo.HandlerFunc {
 
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
-				"artist": a,
+				"artist":           a,
 				"amount_of_albums": amountOfAlbums,
 			},
 		})
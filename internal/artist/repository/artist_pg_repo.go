@@ -5,7 +5,6 @@ import (
 	"2019_2_Covenant/internal/models"
 	. "2019_2_Covenant/tools/vars"
 	"database/sql"
-	"strings"
 )
 
 type ArtistRepository struct {
@@ -58,40 +57,6 @@ func (ar *ArtistRepository) Fetch(count uint64, offset uint64) ([]*models.Artist
 	return artists, total, nil
 }
 
-func (ar *ArtistRepository) FindLike(name string, count uint64) ([]*models.Artist, error) {
-	var artists []*models.Artist
-
-	rows, err := ar.db.Query("select id, name, photo from artists where lower(name) like '%' || $1 || '%' limit $2",
-		strings.ToLower(name),
-		count)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-
-		return nil, err
-	}
-
-	defer rows.Close()
-
-	for rows.Next() {
-		a := &models.Artist{}
-
-		if err := rows.Scan(&a.ID, &a.Name, &a.Photo); err != nil {
-			return nil, err
-		}
-
-		artists = append(artists, a)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return artists, nil
-}
-
 func (ar *ArtistRepository) Store(artist *models.Artist) error {
 	return ar.db.QueryRow("INSERT INTO artists (name) VALUES ($1) RETURNING id, photo",
 		artist.Name,
@@ -189,7 +154,7 @@ func (ar *ArtistRepository) GetArtistAlbums(artistID uint64, count uint64, offse
 		return nil, total, err
 	}
 
-	rows, err := ar.db.Query("SELECT Al.id, Al.name, Al.photo, Al.year, Ar.name, Ar.id FROM albums Al " +
+	rows, err := ar.db.Query("SELECT Al.id, Al.name, Al.photo, Al.year, Ar.name, Ar.id FROM albums Al "+
 		"JOIN artists Ar ON Al.artist_id = Ar.id "+
 		"WHERE Al.artist_id = $1 ORDER BY Al.name LIMIT $2 OFFSET $3",
 		artistID,
@@ -238,7 +203,7 @@ func (ar *ArtistRepository) GetTracks(artistID uint64, count uint64, offset uint
 
 	rows, err := ar.db.Query(
 		"SELECT T.id, T.album_id, T.name, T.duration, Al.photo, Al.name, T.path, Ar.name, Ar.id, "+
-			"T.id in (select track_id from favourites where user_id = $1) as favourite, " +
+			"T.id in (select track_id from favourites where user_id = $1) as favourite, "+
 			"T.id in (select track_id from likes where user_id = $1) AS liked FROM tracks T "+
 			"JOIN albums Al ON T.album_id = Al.id "+
 			"JOIN artists Ar ON Al.artist_id = Ar.id WHERE Ar.id = $2 LIMIT $3 OFFSET $4",
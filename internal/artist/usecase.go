@@ -3,7 +3,6 @@ package artist
 import "2019_2_Covenant/internal/models"
 
 type Usecase interface {
-	FindLike(name string, count uint64) ([]*models.Artist, error)
 	Store(artist *models.Artist) error
 	CreateAlbum(album *models.Album) error
 	DeleteByID(id uint64) error
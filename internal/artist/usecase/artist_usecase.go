@@ -16,20 +16,6 @@ func NewArtistUsecase(repo artist.Repository) artist.Usecase {
 	}
 }
 
-func (aUC *ArtistUsecase) FindLike(name string, count uint64) ([]*models.Artist, error) {
-	artists, err := aUC.artistRepo.FindLike(name, count)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if artists == nil {
-		artists = []*models.Artist{}
-	}
-
-	return artists, nil
-}
-
 func (aUC *ArtistUsecase) Store(artist *models.Artist) error {
 	if err := aUC.artistRepo.Store(artist); err != nil {
 		return err
@@ -105,7 +91,9 @@ func (aUC *ArtistUsecase) GetArtistAlbums(artistID uint64, count uint64, offset
 		albums = []*models.Album{}
 	}
 
-	for _, a := range albums { a.Year = a.Year[:4] }
+	for _, a := range albums {
+		a.Year = a.Year[:4]
+	}
 
 	return albums, total, nil
 }
@@ -121,7 +109,9 @@ func (aUC *ArtistUsecase) GetTracks(artistID uint64, count uint64, offset uint64
 		tracks = []*models.Track{}
 	}
 
-	for _, item := range tracks { item.Duration = time_parser.GetDuration(item.Duration) }
+	for _, item := range tracks {
+		item.Duration = time_parser.GetDuration(item.Duration)
+	}
 
 	return tracks, total, nil
 }
@@ -0,0 +1,153 @@
+package delivery
+
+import (
+	"2019_2_Covenant/internal/blocks"
+	"2019_2_Covenant/internal/middlewares"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/reader"
+	. "2019_2_Covenant/tools/base_handler"
+	. "2019_2_Covenant/tools/response"
+	. "2019_2_Covenant/tools/vars"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+type BlocksHandler struct {
+	BaseHandler
+	BUsecase blocks.Usecase
+}
+
+func NewBlocksHandler(bUC blocks.Usecase,
+	mManager *middlewares.MiddlewareManager,
+	logger *logger.LogrusLogger) *BlocksHandler {
+	return &BlocksHandler{
+		BaseHandler: BaseHandler{
+			MManager:  mManager,
+			Logger:    logger,
+			ReqReader: reader.NewReqReader(),
+		},
+		BUsecase: bUC,
+	}
+}
+
+func (bh *BlocksHandler) Configure(e *echo.Echo) {
+	e.GET("/api/v1/profile/blocked", bh.GetBlocked(), bh.MManager.CheckAuthStrictly, bh.MManager.DenyTokens)
+	e.POST("/api/v1/profile/blocked/:id", bh.Block(), bh.MManager.CheckAuthStrictly, bh.MManager.DenyTokens)
+	e.DELETE("/api/v1/profile/blocked/:id", bh.Unblock(), bh.MManager.CheckAuthStrictly, bh.MManager.DenyTokens)
+	e.GET("/api/v1/profile/muted", bh.GetMuted(), bh.MManager.CheckAuthStrictly, bh.MManager.DenyTokens)
+	e.POST("/api/v1/profile/muted/:id", bh.Mute(), bh.MManager.CheckAuthStrictly, bh.MManager.DenyTokens)
+	e.DELETE("/api/v1/profile/muted/:id", bh.Unmute(), bh.MManager.CheckAuthStrictly, bh.MManager.DenyTokens)
+}
+
+func (bh *BlocksHandler) GetBlocked() echo.HandlerFunc {
+	return bh.fetchRelated(bh.BUsecase.FetchBlocked)
+}
+
+func (bh *BlocksHandler) Block() echo.HandlerFunc {
+	return bh.changeRelation(bh.BUsecase.Block)
+}
+
+func (bh *BlocksHandler) Unblock() echo.HandlerFunc {
+	return bh.changeRelation(bh.BUsecase.Unblock)
+}
+
+func (bh *BlocksHandler) GetMuted() echo.HandlerFunc {
+	return bh.fetchRelated(bh.BUsecase.FetchMuted)
+}
+
+func (bh *BlocksHandler) Mute() echo.HandlerFunc {
+	return bh.changeRelation(bh.BUsecase.Mute)
+}
+
+func (bh *BlocksHandler) Unmute() echo.HandlerFunc {
+	return bh.changeRelation(bh.BUsecase.Unmute)
+}
+
+func (bh *BlocksHandler) fetchRelated(fetch func(uint64, uint64, uint64) ([]*models.User, uint64, error)) echo.HandlerFunc {
+	type Request struct {
+		Count  uint64 `query:"count" validate:"required"`
+		Offset uint64 `query:"offset"`
+	}
+
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			bh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := bh.ReqReader.Read(c, request, nil); err != nil {
+			bh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		users, total, err := fetch(sess.UserID, request.Count, request.Offset)
+
+		if err != nil {
+			bh.Logger.Log(c, "error", "Error while fetching users.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"users": users,
+				"total": total,
+			},
+		})
+	}
+}
+
+func (bh *BlocksHandler) changeRelation(change func(uint64, uint64) error) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			bh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		uID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			bh.Logger.Log(c, "info", "Atoi error.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		if err := change(sess.UserID, uint64(uID)); err != nil {
+			status := http.StatusInternalServerError
+
+			switch err {
+			case ErrBadParam:
+				status = http.StatusBadRequest
+			case ErrNotFound:
+				status = http.StatusNotFound
+			case ErrAlreadyExist:
+				status = http.StatusConflict
+			}
+
+			bh.Logger.Log(c, "info", "Error while changing relation.", err)
+			return c.JSON(status, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"2019_2_Covenant/internal/blocks"
+	"2019_2_Covenant/internal/models"
+	. "2019_2_Covenant/tools/vars"
+	"database/sql"
+	"fmt"
+)
+
+type BlocksRepository struct {
+	db *sql.DB
+}
+
+func NewBlocksRepository(db *sql.DB) blocks.Repository {
+	return &BlocksRepository{
+		db: db,
+	}
+}
+
+// Block also breaks all the subscriptions and follow requests between the users.
+func (bR *BlocksRepository) Block(userID uint64, blockedID uint64) error {
+	tx, err := bR.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if err := insertRelation(tx, "blocks", "blocked_id", userID, blockedID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM subscriptions WHERE (user_id = $1 AND subscribed_to = $2) "+
+		"OR (user_id = $2 AND subscribed_to = $1)",
+		userID,
+		blockedID,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM follow_requests WHERE (user_id = $1 AND requested_to = $2) "+
+		"OR (user_id = $2 AND requested_to = $1)",
+		userID,
+		blockedID,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (bR *BlocksRepository) Unblock(userID uint64, blockedID uint64) error {
+	return bR.deleteRelation("blocks", "blocked_id", userID, blockedID)
+}
+
+func (bR *BlocksRepository) FetchBlocked(userID uint64, count uint64, offset uint64) ([]*models.User, uint64, error) {
+	return bR.fetchRelated("blocks", "blocked_id", userID, count, offset)
+}
+
+func (bR *BlocksRepository) Mute(userID uint64, mutedID uint64) error {
+	tx, err := bR.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if err := insertRelation(tx, "mutes", "muted_id", userID, mutedID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (bR *BlocksRepository) Unmute(userID uint64, mutedID uint64) error {
+	return bR.deleteRelation("mutes", "muted_id", userID, mutedID)
+}
+
+func (bR *BlocksRepository) FetchMuted(userID uint64, count uint64, offset uint64) ([]*models.User, uint64, error) {
+	return bR.fetchRelated("mutes", "muted_id", userID, count, offset)
+}
+
+// insertRelation adds a row to blocks or mutes, returning ErrNotFound for
+// an unknown user and ErrAlreadyExist if the row is already there.
+func insertRelation(tx *sql.Tx, table string, column string, userID uint64, otherID uint64) error {
+	var id uint64
+
+	if err := tx.QueryRow("SELECT id FROM users WHERE id = $1",
+		otherID,
+	).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	if err := tx.QueryRow(fmt.Sprintf("INSERT INTO %s (user_id, %s) VALUES ($1, $2) "+
+		"ON CONFLICT (user_id, %s) DO NOTHING RETURNING id", table, column, column),
+		userID,
+		otherID,
+	).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrAlreadyExist
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (bR *BlocksRepository) deleteRelation(table string, column string, userID uint64, otherID uint64) error {
+	res, err := bR.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND %s = $2", table, column),
+		userID,
+		otherID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (bR *BlocksRepository) fetchRelated(table string, column string, userID uint64, count uint64, offset uint64) ([]*models.User, uint64, error) {
+	var users []*models.User
+	var total uint64
+
+	if err := bR.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE user_id = $1", table),
+		userID,
+	).Scan(&total); err != nil {
+		return nil, total, err
+	}
+
+	rows, err := bR.db.Query(fmt.Sprintf("SELECT U.id, U.nickname, U.avatar, U.role, U.access FROM users U "+
+		"JOIN %s R ON U.id = R.%s WHERE R.user_id = $1 "+
+		"ORDER BY R.created_at DESC LIMIT $2 OFFSET $3", table, column),
+		userID,
+		count,
+		offset,
+	)
+
+	if err != nil {
+		return nil, total, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		u := &models.User{}
+
+		if err := rows.Scan(&u.ID, &u.Nickname, &u.Avatar, &u.Role, &u.Access); err != nil {
+			return nil, total, err
+		}
+
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, total, err
+	}
+
+	return users, total, nil
+}
@@ -0,0 +1,12 @@
+package blocks
+
+import "2019_2_Covenant/internal/models"
+
+type Usecase interface {
+	Block(userID uint64, blockedID uint64) error
+	Unblock(userID uint64, blockedID uint64) error
+	FetchBlocked(userID uint64, count uint64, offset uint64) ([]*models.User, uint64, error)
+	Mute(userID uint64, mutedID uint64) error
+	Unmute(userID uint64, mutedID uint64) error
+	FetchMuted(userID uint64, count uint64, offset uint64) ([]*models.User, uint64, error)
+}
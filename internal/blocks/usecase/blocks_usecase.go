@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"2019_2_Covenant/internal/blocks"
+	"2019_2_Covenant/internal/models"
+	. "2019_2_Covenant/tools/vars"
+)
+
+type BlocksUsecase struct {
+	blocksRepo blocks.Repository
+}
+
+func NewBlocksUsecase(repo blocks.Repository) blocks.Usecase {
+	return &BlocksUsecase{
+		blocksRepo: repo,
+	}
+}
+
+func (bUC *BlocksUsecase) Block(userID uint64, blockedID uint64) error {
+	if userID == blockedID {
+		return ErrBadParam
+	}
+
+	return relationError(bUC.blocksRepo.Block(userID, blockedID))
+}
+
+func (bUC *BlocksUsecase) Unblock(userID uint64, blockedID uint64) error {
+	return relationError(bUC.blocksRepo.Unblock(userID, blockedID))
+}
+
+func (bUC *BlocksUsecase) FetchBlocked(userID uint64, count uint64, offset uint64) ([]*models.User, uint64, error) {
+	users, total, err := bUC.blocksRepo.FetchBlocked(userID, count, offset)
+
+	if err != nil {
+		return nil, total, err
+	}
+
+	if users == nil {
+		users = []*models.User{}
+	}
+
+	return users, total, nil
+}
+
+func (bUC *BlocksUsecase) Mute(userID uint64, mutedID uint64) error {
+	if userID == mutedID {
+		return ErrBadParam
+	}
+
+	return relationError(bUC.blocksRepo.Mute(userID, mutedID))
+}
+
+func (bUC *BlocksUsecase) Unmute(userID uint64, mutedID uint64) error {
+	return relationError(bUC.blocksRepo.Unmute(userID, mutedID))
+}
+
+func (bUC *BlocksUsecase) FetchMuted(userID uint64, count uint64, offset uint64) ([]*models.User, uint64, error) {
+	users, total, err := bUC.blocksRepo.FetchMuted(userID, count, offset)
+
+	if err != nil {
+		return nil, total, err
+	}
+
+	if users == nil {
+		users = []*models.User{}
+	}
+
+	return users, total, nil
+}
+
+func relationError(err error) error {
+	if err == nil || err == ErrNotFound || err == ErrAlreadyExist {
+		return err
+	}
+
+	return ErrInternalServerError
+}
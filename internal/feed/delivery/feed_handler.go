@@ -0,0 +1,81 @@
+package delivery
+
+import (
+	"2019_2_Covenant/internal/feed"
+	"2019_2_Covenant/internal/middlewares"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/reader"
+	. "2019_2_Covenant/tools/base_handler"
+	. "2019_2_Covenant/tools/response"
+	. "2019_2_Covenant/tools/vars"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type FeedHandler struct {
+	BaseHandler
+	FUsecase feed.Usecase
+}
+
+func NewFeedHandler(fUC feed.Usecase,
+	mManager *middlewares.MiddlewareManager,
+	logger *logger.LogrusLogger) *FeedHandler {
+	return &FeedHandler{
+		BaseHandler: BaseHandler{
+			MManager:  mManager,
+			Logger:    logger,
+			ReqReader: reader.NewReqReader(),
+		},
+		FUsecase: fUC,
+	}
+}
+
+func (fh *FeedHandler) Configure(e *echo.Echo) {
+	e.GET("/api/v1/feed", fh.GetFeed(), fh.MManager.CheckAuthStrictly)
+}
+
+// GetFeed pages through activities with the cursor: "before" is the
+// "next_cursor" of the previous page.
+func (fh *FeedHandler) GetFeed() echo.HandlerFunc {
+	type Request struct {
+		Count  uint64 `query:"count" validate:"required,max=100"`
+		Before uint64 `query:"before"`
+	}
+
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			fh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := fh.ReqReader.Read(c, request, nil); err != nil {
+			fh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		activities, next, err := fh.FUsecase.Fetch(sess.UserID, request.Before, request.Count)
+
+		if err != nil {
+			fh.Logger.Log(c, "error", "Error while fetching feed.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"activities":  activities,
+				"next_cursor": next,
+			},
+		})
+	}
+}
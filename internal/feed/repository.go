@@ -0,0 +1,10 @@
+package feed
+
+import "2019_2_Covenant/internal/models"
+
+type Repository interface {
+	Store(userID uint64, kind string, objectID uint64) error
+	Remove(userID uint64, kind string, objectID uint64) error
+	Fetch(viewerID uint64, before uint64, count uint64) ([]*models.Activity, error)
+	FetchAudience(userID uint64, kind string, objectID uint64) ([]uint64, error)
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"2019_2_Covenant/internal/feed"
+	"2019_2_Covenant/internal/models"
+	. "2019_2_Covenant/tools/vars"
+	"database/sql"
+	"fmt"
+)
+
+// Column of activities table the object of each kind of activity is stored in
+var objectColumns = map[string]string{
+	ACTIVITY_LIKE:            "track_id",
+	ACTIVITY_FAVOURITE:       "track_id",
+	ACTIVITY_PLAYLIST_CREATE: "playlist_id",
+	ACTIVITY_PLAYLIST_UPDATE: "playlist_id",
+	ACTIVITY_FOLLOW:          "target_id",
+}
+
+type FeedRepository struct {
+	db *sql.DB
+}
+
+func NewFeedRepository(db *sql.DB) feed.Repository {
+	return &FeedRepository{
+		db: db,
+	}
+}
+
+// Store records the activity replacing the same earlier one, so that
+// e.g. several updates of a playlist appear in the feed only once.
+func (fR *FeedRepository) Store(userID uint64, kind string, objectID uint64) error {
+	column, ok := objectColumns[kind]
+
+	if !ok {
+		return ErrBadParam
+	}
+
+	tx, err := fR.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM activities WHERE user_id = $1 AND type = $2 AND %s = $3", column),
+		userID,
+		kind,
+		objectID,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("INSERT INTO activities (user_id, type, %s) VALUES ($1, $2, $3)", column),
+		userID,
+		kind,
+		objectID,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (fR *FeedRepository) Remove(userID uint64, kind string, objectID uint64) error {
+	column, ok := objectColumns[kind]
+
+	if !ok {
+		return ErrBadParam
+	}
+
+	if _, err := fR.db.Exec(fmt.Sprintf("DELETE FROM activities WHERE user_id = $1 AND type = $2 AND %s = $3", column),
+		userID,
+		kind,
+		objectID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Fetch returns activities of the users the viewer follows, newest first,
+// starting below the before id (0 means from the newest). Activities on
+// non-public playlists, favourites of users hiding them and activities
+// of muted users are skipped.
+func (fR *FeedRepository) Fetch(viewerID uint64, before uint64, count uint64) ([]*models.Activity, error) {
+	var activities []*models.Activity
+
+	rows, err := fR.db.Query("SELECT A.id, A.type, A.created_at, U.id, U.nickname, U.avatar, "+
+		"T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, "+
+		"P.id, P.owner_id, P.name, P.description, P.photo, P.visibility, "+
+		"TU.id, TU.nickname, TU.avatar FROM activities A "+
+		"JOIN subscriptions S ON S.subscribed_to = A.user_id AND S.user_id = $1 "+
+		"JOIN users U ON U.id = A.user_id "+
+		"LEFT JOIN user_settings US ON US.user_id = A.user_id "+
+		"LEFT JOIN tracks T ON T.id = A.track_id "+
+		"LEFT JOIN albums Al ON Al.id = T.album_id "+
+		"LEFT JOIN artists Ar ON Ar.id = Al.artist_id "+
+		"LEFT JOIN playlists P ON P.id = A.playlist_id "+
+		"LEFT JOIN users TU ON TU.id = A.target_id "+
+		"WHERE ($2::bigint = 0 OR A.id < $2) "+
+		"AND (A.type <> $3 OR coalesce(US.show_favourites, true)) "+
+		"AND (A.playlist_id IS NULL OR P.visibility = $4) "+
+		"AND NOT EXISTS (SELECT 1 FROM mutes M WHERE M.user_id = $1 AND M.muted_id = A.user_id) "+
+		"ORDER BY A.id DESC LIMIT $5",
+		viewerID,
+		before,
+		ACTIVITY_FAVOURITE,
+		PLAYLIST_PUBLIC,
+		count,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		a := &models.Activity{User: &models.User{}}
+
+		var trackID, albumID, artistID sql.NullInt64
+		var trackName, duration, albumPhoto, artistName, albumName, trackPath sql.NullString
+		var playlistID, ownerID, visibility sql.NullInt64
+		var playlistName, description, playlistPhoto sql.NullString
+		var targetID sql.NullInt64
+		var targetNickname, targetAvatar sql.NullString
+
+		if err := rows.Scan(&a.ID, &a.Type, &a.CreatedAt, &a.User.ID, &a.User.Nickname, &a.User.Avatar,
+			&trackID, &albumID, &artistID, &trackName, &duration, &albumPhoto, &artistName, &albumName, &trackPath,
+			&playlistID, &ownerID, &playlistName, &description, &playlistPhoto, &visibility,
+			&targetID, &targetNickname, &targetAvatar,
+		); err != nil {
+			return nil, err
+		}
+
+		if trackID.Valid {
+			a.Track = &models.Track{
+				ID:       uint64(trackID.Int64),
+				AlbumID:  uint64(albumID.Int64),
+				ArtistID: uint64(artistID.Int64),
+				Name:     trackName.String,
+				Duration: duration.String,
+				Photo:    albumPhoto.String,
+				Artist:   artistName.String,
+				Album:    albumName.String,
+				Path:     trackPath.String,
+			}
+		}
+
+		if playlistID.Valid {
+			a.Playlist = &models.Playlist{
+				ID:          uint64(playlistID.Int64),
+				OwnerID:     uint64(ownerID.Int64),
+				Name:        playlistName.String,
+				Description: description.String,
+				Photo:       playlistPhoto.String,
+				Visibility:  int8(visibility.Int64),
+			}
+		}
+
+		if targetID.Valid {
+			a.Target = &models.User{
+				ID:       uint64(targetID.Int64),
+				Nickname: targetNickname.String,
+				Avatar:   targetAvatar.String,
+			}
+		}
+
+		activities = append(activities, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+// FetchAudience returns the followers who will see the activity in their
+// feeds, applying the same rules as Fetch.
+func (fR *FeedRepository) FetchAudience(userID uint64, kind string, objectID uint64) ([]uint64, error) {
+	var ids []uint64
+
+	rows, err := fR.db.Query("SELECT S.user_id FROM subscriptions S "+
+		"LEFT JOIN user_settings US ON US.user_id = S.subscribed_to "+
+		"WHERE S.subscribed_to = $1 "+
+		"AND NOT EXISTS (SELECT 1 FROM mutes M WHERE M.user_id = S.user_id AND M.muted_id = $1) "+
+		"AND ($2::varchar <> $3 OR coalesce(US.show_favourites, true)) "+
+		"AND ($2 NOT IN ($4, $5) OR EXISTS (SELECT 1 FROM playlists WHERE id = $6 AND visibility = $7))",
+		userID,
+		kind,
+		ACTIVITY_FAVOURITE,
+		ACTIVITY_PLAYLIST_CREATE,
+		ACTIVITY_PLAYLIST_UPDATE,
+		objectID,
+		PLAYLIST_PUBLIC,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
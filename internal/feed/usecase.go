@@ -0,0 +1,7 @@
+package feed
+
+import "2019_2_Covenant/internal/models"
+
+type Usecase interface {
+	Fetch(viewerID uint64, before uint64, count uint64) ([]*models.Activity, uint64, error)
+}
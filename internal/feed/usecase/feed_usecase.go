@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"2019_2_Covenant/internal/feed"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/tools/time_parser"
+)
+
+type FeedUsecase struct {
+	feedRepo feed.Repository
+}
+
+func NewFeedUsecase(repo feed.Repository) feed.Usecase {
+	return &FeedUsecase{
+		feedRepo: repo,
+	}
+}
+
+// Fetch returns a page of the viewer's feed and the cursor of the next
+// page, which is 0 if there are no more activities.
+func (fUC *FeedUsecase) Fetch(viewerID uint64, before uint64, count uint64) ([]*models.Activity, uint64, error) {
+	activities, err := fUC.feedRepo.Fetch(viewerID, before, count)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if activities == nil {
+		activities = []*models.Activity{}
+	}
+
+	for _, a := range activities {
+		if a.Track != nil {
+			a.Track.Duration = time_parser.GetDuration(a.Track.Duration)
+		}
+	}
+
+	var next uint64
+
+	if uint64(len(activities)) == count {
+		next = activities[len(activities)-1].ID
+	}
+
+	return activities, next, nil
+}
@@ -0,0 +1,273 @@
+package delivery
+
+import (
+	"2019_2_Covenant/internal/history"
+	"2019_2_Covenant/internal/middlewares"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/user"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/reader"
+	. "2019_2_Covenant/tools/base_handler"
+	. "2019_2_Covenant/tools/response"
+	. "2019_2_Covenant/tools/vars"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+type HistoryHandler struct {
+	BaseHandler
+	HUsecase history.Usecase
+	UUsecase user.Usecase
+}
+
+func NewHistoryHandler(hUC history.Usecase,
+	uUC user.Usecase,
+	mManager *middlewares.MiddlewareManager,
+	logger *logger.LogrusLogger) *HistoryHandler {
+	return &HistoryHandler{
+		BaseHandler: BaseHandler{
+			MManager:  mManager,
+			Logger:    logger,
+			ReqReader: reader.NewReqReader(),
+		},
+		HUsecase: hUC,
+		UUsecase: uUC,
+	}
+}
+
+func (hh *HistoryHandler) Configure(e *echo.Echo) {
+	write := hh.MManager.TokenScope(TOKEN_SCOPE_LIBRARY_WRITE)
+
+	e.GET("/api/v1/profile/history", hh.GetHistory(), hh.MManager.CheckAuthStrictly)
+	e.POST("/api/v1/profile/history", hh.AddToHistory(), write, hh.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/profile/history", hh.ClearHistory(), write, hh.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/profile/history/:id", hh.RemoveFromHistory(), write, hh.MManager.CheckAuthStrictly)
+	e.GET("/api/v1/users/:id/history", hh.GetUserHistory(), hh.MManager.CheckAuthStrictly)
+}
+
+func (hh *HistoryHandler) GetHistory() echo.HandlerFunc {
+	type Request struct {
+		Count  uint64 `query:"count" validate:"required"`
+		Offset uint64 `query:"offset"`
+	}
+
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			hh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := hh.ReqReader.Read(c, request, nil); err != nil {
+			hh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		items, total, err := hh.HUsecase.Fetch(sess.UserID, request.Count, request.Offset)
+
+		if err != nil {
+			hh.Logger.Log(c, "error", "Error while fetching history.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"history": items,
+				"total":   total,
+			},
+		})
+	}
+}
+
+func (hh *HistoryHandler) AddToHistory() echo.HandlerFunc {
+	type Request struct {
+		TrackID uint64 `json:"track_id" validate:"required"`
+	}
+
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			hh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := hh.ReqReader.Read(c, request, nil); err != nil {
+			hh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if err := hh.HUsecase.Store(sess.UserID, request.TrackID); err != nil {
+			if err == ErrNotFound {
+				hh.Logger.Log(c, "info", "Track not found.", request.TrackID)
+				return c.JSON(http.StatusNotFound, Response{
+					Error: err.Error(),
+				})
+			}
+
+			hh.Logger.Log(c, "error", "Error while storing play.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (hh *HistoryHandler) RemoveFromHistory() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			hh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		hID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			hh.Logger.Log(c, "info", "Atoi error.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		if err := hh.HUsecase.DeleteByID(sess.UserID, uint64(hID)); err != nil {
+			if err == ErrNotFound {
+				hh.Logger.Log(c, "info", "History item not found.", hID)
+				return c.JSON(http.StatusNotFound, Response{
+					Error: err.Error(),
+				})
+			}
+
+			hh.Logger.Log(c, "error", "Error while removing history item.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (hh *HistoryHandler) ClearHistory() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			hh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := hh.HUsecase.Clear(sess.UserID); err != nil {
+			hh.Logger.Log(c, "error", "Error while clearing history.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+// GetUserHistory shows another user's history if they chose to share it.
+func (hh *HistoryHandler) GetUserHistory() echo.HandlerFunc {
+	type Request struct {
+		Count  uint64 `query:"count" validate:"required"`
+		Offset uint64 `query:"offset"`
+	}
+
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			hh.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		uID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			hh.Logger.Log(c, "info", "Invalid user id.", err)
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := hh.ReqReader.Read(c, request, nil); err != nil {
+			hh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		allowed, err := hh.UUsecase.CanViewHistory(uint64(uID), usr)
+
+		if err != nil {
+			status := http.StatusInternalServerError
+
+			if err == ErrNotFound {
+				status = http.StatusNotFound
+			}
+
+			hh.Logger.Log(c, "info", "Error while checking access to history.", err)
+			return c.JSON(status, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if !allowed {
+			hh.Logger.Log(c, "info", "Can't view user's history.")
+			return c.JSON(http.StatusForbidden, Response{
+				Error: ErrPermissionDenied.Error(),
+			})
+		}
+
+		items, total, err := hh.HUsecase.Fetch(uint64(uID), request.Count, request.Offset)
+
+		if err != nil {
+			hh.Logger.Log(c, "error", "Error while fetching history.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"history": items,
+				"total":   total,
+			},
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"2019_2_Covenant/internal/history"
+	"2019_2_Covenant/internal/models"
+	. "2019_2_Covenant/tools/vars"
+	"database/sql"
+)
+
+type HistoryRepository struct {
+	db *sql.DB
+}
+
+func NewHistoryRepository(db *sql.DB) history.Repository {
+	return &HistoryRepository{
+		db: db,
+	}
+}
+
+func (hR *HistoryRepository) Store(userID uint64, trackID uint64) error {
+	var id uint64
+
+	if err := hR.db.QueryRow("SELECT id FROM tracks WHERE id = $1",
+		trackID,
+	).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	if _, err := hR.db.Exec("INSERT INTO plays (user_id, track_id) VALUES ($1, $2)",
+		userID,
+		trackID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (hR *HistoryRepository) Fetch(userID uint64, count uint64, offset uint64) ([]*models.HistoryItem, uint64, error) {
+	var items []*models.HistoryItem
+	var total uint64
+
+	if err := hR.db.QueryRow("SELECT COUNT(*) FROM plays WHERE user_id = $1",
+		userID,
+	).Scan(&total); err != nil {
+		return nil, total, err
+	}
+
+	rows, err := hR.db.Query(
+		"SELECT P.id, P.played_at, T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, "+
+			"T.id in (select track_id from favourites where user_id = $1) AS favourite, "+
+			"T.id in (select track_id from likes where user_id = $1) AS liked FROM plays P "+
+			"JOIN tracks T ON P.track_id = T.id "+
+			"JOIN albums Al ON T.album_id = Al.id "+
+			"JOIN artists Ar ON Al.artist_id = Ar.id "+
+			"WHERE P.user_id = $1 ORDER BY P.played_at DESC, P.id DESC LIMIT $2 OFFSET $3",
+		userID,
+		count,
+		offset,
+	)
+
+	if err != nil {
+		return nil, total, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		item := &models.HistoryItem{Track: &models.Track{}}
+		t := item.Track
+
+		if err := rows.Scan(&item.ID, &item.PlayedAt, &t.ID, &t.AlbumID, &t.ArtistID, &t.Name, &t.Duration,
+			&t.Photo, &t.Artist, &t.Album, &t.Path, &t.IsFavourite, &t.IsLiked,
+		); err != nil {
+			return nil, total, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, total, err
+	}
+
+	return items, total, nil
+}
+
+func (hR *HistoryRepository) DeleteByID(userID uint64, itemID uint64) error {
+	res, err := hR.db.Exec("DELETE FROM plays WHERE id = $1 AND user_id = $2",
+		itemID,
+		userID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (hR *HistoryRepository) Clear(userID uint64) error {
+	if _, err := hR.db.Exec("DELETE FROM plays WHERE user_id = $1",
+		userID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
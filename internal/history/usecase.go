@@ -0,0 +1,10 @@
+package history
+
+import "2019_2_Covenant/internal/models"
+
+type Usecase interface {
+	Store(userID uint64, trackID uint64) error
+	Fetch(userID uint64, count uint64, offset uint64) ([]*models.HistoryItem, uint64, error)
+	DeleteByID(userID uint64, itemID uint64) error
+	Clear(userID uint64) error
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"2019_2_Covenant/internal/history"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/tools/time_parser"
+	. "2019_2_Covenant/tools/vars"
+)
+
+type HistoryUsecase struct {
+	historyRepo history.Repository
+}
+
+func NewHistoryUsecase(repo history.Repository) history.Usecase {
+	return &HistoryUsecase{
+		historyRepo: repo,
+	}
+}
+
+func (hUC *HistoryUsecase) Store(userID uint64, trackID uint64) error {
+	err := hUC.historyRepo.Store(userID, trackID)
+
+	if err == ErrNotFound {
+		return err
+	}
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (hUC *HistoryUsecase) Fetch(userID uint64, count uint64, offset uint64) ([]*models.HistoryItem, uint64, error) {
+	items, total, err := hUC.historyRepo.Fetch(userID, count, offset)
+
+	if err != nil {
+		return nil, total, err
+	}
+
+	if items == nil {
+		items = []*models.HistoryItem{}
+	}
+
+	for _, item := range items {
+		item.Track.Duration = time_parser.GetDuration(item.Track.Duration)
+	}
+
+	return items, total, nil
+}
+
+func (hUC *HistoryUsecase) DeleteByID(userID uint64, itemID uint64) error {
+	err := hUC.historyRepo.DeleteByID(userID, itemID)
+
+	if err == ErrNotFound {
+		return err
+	}
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (hUC *HistoryUsecase) Clear(userID uint64) error {
+	if err := hUC.historyRepo.Clear(userID); err != nil {
+		return ErrInternalServerError
+	}
+
+	return nil
+}
@@ -36,8 +36,10 @@ func NewLikesHandler(lUC likes.Usecase,
 }
 
 func (lh *LikesHandler) Configure(e *echo.Echo) {
-	e.POST("/api/v1/likes", lh.Like(), lh.MManager.CheckAuthStrictly)
-	e.DELETE("/api/v1/likes", lh.Unlike(), lh.MManager.CheckAuthStrictly)
+	write := lh.MManager.TokenScope(TOKEN_SCOPE_LIBRARY_WRITE)
+
+	e.POST("/api/v1/likes", lh.Like(), write, lh.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/likes", lh.Unlike(), write, lh.MManager.CheckAuthStrictly)
 }
 
 func (lh *LikesHandler) Like() echo.HandlerFunc {
@@ -33,14 +33,6 @@ func (ssR *LikesRepository) Like(userID uint64, trackID uint64) error {
 		return err
 	}
 
-	if err := ssR.db.QueryRow("UPDATE tracks SET rating = rating + 1 WHERE id = $1 RETURNING id",
-		trackID,
-	).Scan(
-		&id,
-	); err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -59,14 +51,5 @@ func (ssR *LikesRepository) Unlike(userID uint64, trackID uint64) error {
 		return ErrNotFound
 	}
 
-	var id int
-	if err := ssR.db.QueryRow("UPDATE tracks SET rating = rating - 1 WHERE id = $1 RETURNING id",
-		trackID,
-	).Scan(
-		&id,
-	); err != nil {
-		return err
-	}
-
 	return nil
 }
@@ -1,17 +1,23 @@
 package usecase
 
 import (
+	"2019_2_Covenant/internal/feed"
 	"2019_2_Covenant/internal/likes"
+	"2019_2_Covenant/pkg/logger"
 	. "2019_2_Covenant/tools/vars"
 )
 
 type LikesUsecase struct {
 	likesRepo likes.Repository
+	feedRepo  feed.Repository
+	logger    *logger.LogrusLogger
 }
 
-func NewLikesUsecase(repo likes.Repository) likes.Usecase {
+func NewLikesUsecase(repo likes.Repository, fRepo feed.Repository, logger *logger.LogrusLogger) likes.Usecase {
 	return &LikesUsecase{
 		likesRepo: repo,
+		feedRepo:  fRepo,
+		logger:    logger,
 	}
 }
 
@@ -26,6 +32,10 @@ func (lUC *LikesUsecase) Like(userID uint64, trackID uint64) error {
 		return ErrInternalServerError
 	}
 
+	if err := lUC.feedRepo.Store(userID, ACTIVITY_LIKE, trackID); err != nil {
+		lUC.logger.L.Error(err)
+	}
+
 	return nil
 }
 
@@ -40,5 +50,9 @@ func (lUC *LikesUsecase) Unlike(userID uint64, trackID uint64) error {
 		return ErrInternalServerError
 	}
 
+	if err := lUC.feedRepo.Remove(userID, ACTIVITY_LIKE, trackID); err != nil {
+		lUC.logger.L.Error(err)
+	}
+
 	return nil
 }
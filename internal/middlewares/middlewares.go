@@ -3,6 +3,7 @@ package middlewares
 import (
 	"2019_2_Covenant/internal/models"
 	"2019_2_Covenant/internal/session"
+	"2019_2_Covenant/internal/tokens"
 	"2019_2_Covenant/internal/user"
 	"2019_2_Covenant/pkg/logger"
 	. "2019_2_Covenant/tools/response"
@@ -11,27 +12,47 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"strings"
 	"time"
 )
 
 type MiddlewareManager struct {
-	sUC    session.Usecase
-	uUC    user.Usecase
-	logger *logger.LogrusLogger
+	sUC        session.Usecase
+	uUC        user.Usecase
+	tUC        tokens.Usecase
+	restricted map[string]bool
+	logger     *logger.LogrusLogger
 }
 
+// NewMiddlewareManager creates the manager forbidding the restricted
+// actions (RESTRICT_* constants) to users with unverified email.
 func NewMiddlewareManager(uUsecase user.Usecase,
 	sUsecase session.Usecase,
+	tUsecase tokens.Usecase,
+	restricted []string,
 	logger *logger.LogrusLogger) *MiddlewareManager {
-	return &MiddlewareManager{
-		sUC:    sUsecase,
-		uUC:    uUsecase,
-		logger: logger,
+	m := &MiddlewareManager{
+		sUC:        sUsecase,
+		uUC:        uUsecase,
+		tUC:        tUsecase,
+		restricted: make(map[string]bool),
+		logger:     logger,
 	}
+
+	for _, action := range restricted {
+		m.restricted[action] = true
+	}
+
+	return m
 }
 
 func (m *MiddlewareManager) CSRFCheckMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		// Browsers don't attach bearer tokens by themselves, so there is nothing to forge
+		if _, ok := c.Get("token").(*models.Token); ok {
+			return next(c)
+		}
+
 		token := c.Request().Header.Get("X-Csrf-Token")
 
 		sess := c.Get("session").(*models.Session)
@@ -68,15 +89,20 @@ func (m *MiddlewareManager) AccessLogMiddleware(next echo.HandlerFunc) echo.Hand
 	}
 }
 
+// AllowedOrigin reports whether the frontend at origin may make credentialed requests
+func (m *MiddlewareManager) AllowedOrigin(origin string) bool {
+	return origin == "http://localhost:3000" || origin == "http://front.covenant.fun:3000" || origin == "http://front.covenant.fun:5000"
+}
+
 func (m *MiddlewareManager) CORSMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		origin := c.Request().Header.Get("Origin")
 
-		if origin == "http://localhost:3000" || origin == "http://front.covenant.fun:3000" || origin == "http://front.covenant.fun:5000"{
+		if m.AllowedOrigin(origin) {
 			c.Response().Header().Set("Access-Control-Allow-Origin", origin)
 		}
 
-		c.Response().Header().Set("Access-Control-Allow-Methods", "POST, GET, PUT, OPTIONS, DELETE")
+		c.Response().Header().Set("Access-Control-Allow-Methods", "POST, GET, PUT, PATCH, OPTIONS, DELETE")
 		c.Response().Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Response().Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 
@@ -103,6 +129,16 @@ func (m *MiddlewareManager) PanicRecovering(next echo.HandlerFunc) echo.HandlerF
 
 func (m *MiddlewareManager) CheckAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		if plain, ok := bearerToken(c); ok {
+			if status, err := m.authenticateToken(c, plain); err != nil {
+				return c.JSON(status, Response{
+					Error: err.Error(),
+				})
+			}
+
+			return next(c)
+		}
+
 		cookie, err := c.Cookie("Covenant")
 
 		if err != nil {
@@ -115,14 +151,29 @@ func (m *MiddlewareManager) CheckAuth(next echo.HandlerFunc) echo.HandlerFunc {
 			return next(c)
 		}
 
+		m.touch(c, sess)
 		c.Set("session", sess)
 
+		if usr, err := m.uUC.GetByID(sess.UserID); err == nil {
+			c.Set("user", usr)
+		}
+
 		return next(c)
 	}
 }
 
 func (m *MiddlewareManager) CheckAuthStrictly(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		if plain, ok := bearerToken(c); ok {
+			if status, err := m.authenticateToken(c, plain); err != nil {
+				return c.JSON(status, Response{
+					Error: err.Error(),
+				})
+			}
+
+			return next(c)
+		}
+
 		cookie, err := c.Cookie("Covenant")
 
 		if err != nil {
@@ -150,6 +201,7 @@ func (m *MiddlewareManager) CheckAuthStrictly(next echo.HandlerFunc) echo.Handle
 			})
 		}
 
+		m.touch(c, sess)
 		c.Set("session", sess)
 		c.Set("user", usr)
 
@@ -157,6 +209,32 @@ func (m *MiddlewareManager) CheckAuthStrictly(next echo.HandlerFunc) echo.Handle
 	}
 }
 
+// touch records when and from where the session was used last,
+// renewing the cookie and the CSRF token if the session was prolonged
+func (m *MiddlewareManager) touch(c echo.Context, sess *models.Session) {
+	renewed, err := m.sUC.Touch(sess, c.RealIP())
+
+	if err != nil {
+		m.logger.Log(c, "error", "Error while touching session.", err)
+		return
+	}
+
+	if !renewed {
+		return
+	}
+
+	c.SetCookie(sess.Cookie())
+
+	token, err := models.NewCSRFTokenManager("Covenant").Create(sess.UserID, sess.Data, sess.Expires)
+
+	if err != nil {
+		m.logger.Log(c, "error", "CSRF Token generating error.", err)
+		return
+	}
+
+	c.Response().Header().Set("X-CSRF-Token", token)
+}
+
 func (m *MiddlewareManager) CheckAdmin(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		usr, ok := c.Get("user").(*models.User)
@@ -175,6 +253,127 @@ func (m *MiddlewareManager) CheckAdmin(next echo.HandlerFunc) echo.HandlerFunc {
 			})
 		}
 
+		if token, ok := c.Get("token").(*models.Token); ok && !token.Allows(TOKEN_SCOPE_ADMIN) {
+			m.logger.Log(c, "info", "Token without admin scope.", token.ID)
+			return c.JSON(http.StatusForbidden, Response{
+				Error: ErrPermissionDenied.Error(),
+			})
+		}
+
 		return next(c)
 	}
 }
+
+// Restricted reports whether the action is forbidden to the user until they verify their email.
+func (m *MiddlewareManager) Restricted(usr *models.User, action string) bool {
+	return !usr.Verified && m.restricted[action]
+}
+
+// CheckVerified forbids the route to users with unverified email if the
+// action is restricted. It must follow CheckAuthStrictly.
+func (m *MiddlewareManager) CheckVerified(action string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			usr, ok := c.Get("user").(*models.User)
+
+			if !ok {
+				m.logger.Log(c, "error", "Can't extract user from echo.Context.")
+				return c.JSON(http.StatusInternalServerError, Response{
+					Error: ErrInternalServerError.Error(),
+				})
+			}
+
+			if m.Restricted(usr, action) {
+				m.logger.Log(c, "info", "Email is not verified.", action)
+				return c.JSON(http.StatusForbidden, Response{
+					Error: ErrNotVerified.Error(),
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// TokenScope declares the scope an API token needs to use the route.
+// It must precede CheckAuth or CheckAuthStrictly.
+func (m *MiddlewareManager) TokenScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("token_scope", scope)
+			return next(c)
+		}
+	}
+}
+
+// DenyTokens limits the route to cookie sessions. It must follow CheckAuthStrictly.
+func (m *MiddlewareManager) DenyTokens(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if token, ok := c.Get("token").(*models.Token); ok {
+			m.logger.Log(c, "info", "Route is not available with API token.", token.ID)
+			return c.JSON(http.StatusForbidden, Response{
+				Error: ErrPermissionDenied.Error(),
+			})
+		}
+
+		return next(c)
+	}
+}
+
+func bearerToken(c echo.Context) (string, bool) {
+	header := c.Request().Header.Get(echo.HeaderAuthorization)
+
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", false
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")), true
+}
+
+// authenticateToken puts the token owner into echo.Context along with a
+// session carrying only the user ID, which is what handlers read from it.
+// Safe methods need read-only scope, the others are only available with
+// a token if the route declares its scope with TokenScope.
+func (m *MiddlewareManager) authenticateToken(c echo.Context, plain string) (int, error) {
+	token, err := m.tUC.Authenticate(plain)
+
+	if err != nil {
+		m.logger.Log(c, "info", "Error while authenticating token:", err.Error())
+
+		if err == ErrUnathorized {
+			return http.StatusUnauthorized, err
+		}
+
+		return http.StatusInternalServerError, err
+	}
+
+	scope, declared := c.Get("token_scope").(string)
+
+	if !declared {
+		switch c.Request().Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			scope = TOKEN_SCOPE_READ_ONLY
+		default:
+			m.logger.Log(c, "info", "Route is not available with API token.", token.ID)
+			return http.StatusForbidden, ErrPermissionDenied
+		}
+	}
+
+	if !token.Allows(scope) {
+		m.logger.Log(c, "info", "Token scope is not enough.", token.ID, token.Scope)
+		return http.StatusForbidden, ErrPermissionDenied
+	}
+
+	usr, err := m.uUC.GetByID(token.UserID)
+
+	if err != nil {
+		m.logger.Log(c, "info", "Error while getting user by id:", err.Error())
+		return http.StatusUnauthorized, ErrUnathorized
+	}
+
+	c.Set("token", token)
+	c.Set("session", &models.Session{UserID: usr.ID})
+	c.Set("user", usr)
+
+	return 0, nil
+}
@@ -0,0 +1,11 @@
+create table activities (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    type varchar not null,
+    track_id bigint references tracks(id) on delete cascade,
+    playlist_id bigint references playlists(id) on delete cascade,
+    target_id bigint references users(id) on delete cascade,
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX activities_user_id_index on activities (user_id, id DESC);
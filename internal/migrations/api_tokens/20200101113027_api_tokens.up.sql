@@ -0,0 +1,11 @@
+create table api_tokens (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    name varchar not null,
+    scope varchar not null,
+    token_hash varchar not null unique,
+    last_used timestamp,
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX api_tokens_user_id_index on api_tokens (user_id);
@@ -0,0 +1,8 @@
+create table blocks (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    blocked_id bigint not null references users(id) on delete cascade,
+    created_at timestamp not null default now(),
+    unique (user_id, blocked_id),
+    check (user_id != blocked_id)
+);
@@ -0,0 +1 @@
+drop table follow_requests cascade;
@@ -0,0 +1,10 @@
+create table follow_requests (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    requested_to bigint not null references users(id) on delete cascade,
+    created_at timestamp not null default now(),
+    unique (user_id, requested_to),
+    check (user_id != requested_to)
+);
+
+CREATE INDEX follow_requests_requested_to_index on follow_requests (requested_to, created_at DESC);
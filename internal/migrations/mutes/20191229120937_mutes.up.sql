@@ -0,0 +1,8 @@
+create table mutes (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    muted_id bigint not null references users(id) on delete cascade,
+    created_at timestamp not null default now(),
+    unique (user_id, muted_id),
+    check (user_id != muted_id)
+);
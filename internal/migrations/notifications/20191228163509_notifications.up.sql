@@ -0,0 +1,12 @@
+create table notifications (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    type varchar not null,
+    actor_id bigint not null references users(id) on delete cascade,
+    playlist_id bigint references playlists(id) on delete cascade,
+    read boolean not null default false,
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX notifications_user_id_index on notifications (user_id, id DESC);
+CREATE INDEX notifications_unread_index on notifications (user_id) where not read;
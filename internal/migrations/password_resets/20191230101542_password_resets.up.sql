@@ -0,0 +1,10 @@
+create table password_resets (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    token_hash varchar not null unique,
+    expires timestamp not null,
+    used_at timestamp,
+    created_at timestamp not null default now()
+);
+
+CREATE INDEX password_resets_user_id_index on password_resets (user_id);
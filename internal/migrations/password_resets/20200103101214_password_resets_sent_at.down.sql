@@ -0,0 +1 @@
+alter table users drop column password_reset_sent_at;
@@ -0,0 +1,10 @@
+create table playlist_collaborators (
+    id bigserial not null primary key,
+    playlist_id bigint not null references playlists(id) on delete cascade,
+    user_id bigint not null references users(id) on delete cascade,
+    accepted boolean not null default false,
+    created_at timestamp not null default now(),
+    unique (playlist_id, user_id),
+    constraint FK_COLLABS_TO_PLAYLISTS FOREIGN KEY (playlist_id) REFERENCES playlists(id),
+    constraint FK_COLLABS_TO_USERS FOREIGN KEY (user_id) REFERENCES users(id)
+)
@@ -0,0 +1 @@
+drop table playlist_likes cascade;
@@ -0,0 +1,7 @@
+create table playlist_likes (
+    id bigserial not null primary key,
+    playlist_id bigint not null references playlists(id) on delete cascade,
+    user_id bigint not null references users(id) on delete cascade,
+    created_at timestamp not null default now(),
+    unique (playlist_id, user_id)
+);
@@ -0,0 +1,9 @@
+alter table playlist_track drop constraint if exists playlist_track_position_unique;
+
+delete from playlist_track PT using playlist_track D
+    where PT.playlist_id = D.playlist_id and PT.track_id = D.track_id and PT.id > D.id;
+
+alter table playlist_track add constraint playlist_track_playlist_id_track_id_key unique (playlist_id, track_id);
+alter table playlist_track drop column position;
+
+alter table playlists drop column allow_duplicates;
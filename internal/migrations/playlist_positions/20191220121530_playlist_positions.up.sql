@@ -0,0 +1,11 @@
+alter table playlists add column allow_duplicates boolean not null default false;
+
+alter table playlist_track add column position bigint not null default 0;
+
+update playlist_track PT set position = S.rn - 1 from (
+    select id, row_number() over (partition by playlist_id order by created_at, id) rn from playlist_track
+) S where PT.id = S.id;
+
+alter table playlist_track drop constraint if exists playlist_track_playlist_id_track_id_key;
+alter table playlist_track add constraint playlist_track_position_unique
+    unique (playlist_id, position) deferrable initially deferred;
@@ -0,0 +1,2 @@
+alter table playlists drop column link;
+alter table playlists drop column visibility;
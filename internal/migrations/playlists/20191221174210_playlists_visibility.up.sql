@@ -0,0 +1,2 @@
+alter table playlists add column visibility int not null default 0;
+alter table playlists add column link varchar not null unique default md5(random()::text || clock_timestamp()::text);
@@ -0,0 +1 @@
+alter table playlists drop column custom_photo;
@@ -0,0 +1 @@
+alter table playlists add column custom_photo boolean not null default false;
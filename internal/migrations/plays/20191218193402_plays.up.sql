@@ -0,0 +1,10 @@
+create table plays (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    track_id bigint not null references tracks(id) on delete cascade,
+    played_at timestamp not null default now(),
+    constraint FK_PLAYS_TO_TRACKS FOREIGN KEY (track_id) REFERENCES tracks(id),
+    constraint FK_PLAYS_TO_USERS FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX plays_user_played_at_index on plays (user_id, played_at DESC);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS tracks_name_trgm_index;
+DROP INDEX IF EXISTS albums_name_trgm_index;
+DROP INDEX IF EXISTS artists_name_trgm_index;
+DROP INDEX IF EXISTS users_nickname_trgm_index;
+
+DROP INDEX IF EXISTS tracks_name_fts_index;
+DROP INDEX IF EXISTS albums_name_fts_index;
+DROP INDEX IF EXISTS artists_name_fts_index;
+
+DROP INDEX IF EXISTS track_charts_track_index;
@@ -0,0 +1,12 @@
+create extension if not exists pg_trgm;
+
+CREATE INDEX tracks_name_trgm_index on tracks using gin (lower(name) gin_trgm_ops);
+CREATE INDEX albums_name_trgm_index on albums using gin (lower(name) gin_trgm_ops);
+CREATE INDEX artists_name_trgm_index on artists using gin (lower(name) gin_trgm_ops);
+CREATE INDEX users_nickname_trgm_index on users using gin (lower(nickname) gin_trgm_ops);
+
+CREATE INDEX tracks_name_fts_index on tracks using gin (to_tsvector('simple', name));
+CREATE INDEX albums_name_fts_index on albums using gin (to_tsvector('simple', name));
+CREATE INDEX artists_name_fts_index on artists using gin (to_tsvector('simple', name));
+
+CREATE INDEX track_charts_track_index on track_charts (period, track_id);
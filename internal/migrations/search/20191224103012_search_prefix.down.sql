@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS tracks_name_prefix_index;
+DROP INDEX IF EXISTS albums_name_prefix_index;
+DROP INDEX IF EXISTS artists_name_prefix_index;
+DROP INDEX IF EXISTS users_nickname_prefix_index;
@@ -0,0 +1,4 @@
+CREATE INDEX tracks_name_prefix_index on tracks (lower(name) text_pattern_ops);
+CREATE INDEX albums_name_prefix_index on albums (lower(name) text_pattern_ops);
+CREATE INDEX artists_name_prefix_index on artists (lower(name) text_pattern_ops);
+CREATE INDEX users_nickname_prefix_index on users (lower(nickname) text_pattern_ops);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS playlists_name_trgm_index;
+DROP INDEX IF EXISTS playlists_description_trgm_index;
+
+DROP INDEX IF EXISTS playlists_name_fts_index;
+DROP INDEX IF EXISTS playlists_description_fts_index;
@@ -0,0 +1,5 @@
+CREATE INDEX playlists_name_trgm_index on playlists using gin (lower(name) gin_trgm_ops);
+CREATE INDEX playlists_description_trgm_index on playlists using gin (lower(coalesce(description, '')) gin_trgm_ops);
+
+CREATE INDEX playlists_name_fts_index on playlists using gin (to_tsvector('simple', name));
+CREATE INDEX playlists_description_fts_index on playlists using gin (to_tsvector('simple', coalesce(description, '')));
@@ -0,0 +1,35 @@
+DROP INDEX IF EXISTS tracks_name_trgm_index;
+DROP INDEX IF EXISTS albums_name_trgm_index;
+DROP INDEX IF EXISTS artists_name_trgm_index;
+DROP INDEX IF EXISTS users_nickname_trgm_index;
+DROP INDEX IF EXISTS playlists_name_trgm_index;
+DROP INDEX IF EXISTS playlists_description_trgm_index;
+
+DROP INDEX IF EXISTS tracks_name_fts_index;
+DROP INDEX IF EXISTS albums_name_fts_index;
+DROP INDEX IF EXISTS artists_name_fts_index;
+DROP INDEX IF EXISTS playlists_name_fts_index;
+DROP INDEX IF EXISTS playlists_description_fts_index;
+
+DROP INDEX IF EXISTS tracks_name_prefix_index;
+DROP INDEX IF EXISTS albums_name_prefix_index;
+DROP INDEX IF EXISTS artists_name_prefix_index;
+DROP INDEX IF EXISTS users_nickname_prefix_index;
+
+CREATE INDEX tracks_name_trgm_index on tracks using gin (lower(name) gin_trgm_ops);
+CREATE INDEX albums_name_trgm_index on albums using gin (lower(name) gin_trgm_ops);
+CREATE INDEX artists_name_trgm_index on artists using gin (lower(name) gin_trgm_ops);
+CREATE INDEX users_nickname_trgm_index on users using gin (lower(nickname) gin_trgm_ops);
+CREATE INDEX playlists_name_trgm_index on playlists using gin (lower(name) gin_trgm_ops);
+CREATE INDEX playlists_description_trgm_index on playlists using gin (lower(coalesce(description, '')) gin_trgm_ops);
+
+CREATE INDEX tracks_name_fts_index on tracks using gin (to_tsvector('simple', name));
+CREATE INDEX albums_name_fts_index on albums using gin (to_tsvector('simple', name));
+CREATE INDEX artists_name_fts_index on artists using gin (to_tsvector('simple', name));
+CREATE INDEX playlists_name_fts_index on playlists using gin (to_tsvector('simple', name));
+CREATE INDEX playlists_description_fts_index on playlists using gin (to_tsvector('simple', coalesce(description, '')));
+
+CREATE INDEX tracks_name_prefix_index on tracks (lower(name) text_pattern_ops);
+CREATE INDEX albums_name_prefix_index on albums (lower(name) text_pattern_ops);
+CREATE INDEX artists_name_prefix_index on artists (lower(name) text_pattern_ops);
+CREATE INDEX users_nickname_prefix_index on users (lower(nickname) text_pattern_ops);
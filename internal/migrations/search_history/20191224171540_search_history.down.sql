@@ -0,0 +1 @@
+drop table search_history cascade;
@@ -0,0 +1,9 @@
+create table search_history (
+    id bigserial not null primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    query varchar not null,
+    searched_at timestamp not null default now(),
+    unique (user_id, query)
+);
+
+CREATE INDEX search_history_user_searched_at_index on search_history (user_id, searched_at DESC);
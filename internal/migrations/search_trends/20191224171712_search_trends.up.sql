@@ -0,0 +1,8 @@
+create table search_trends (
+    query varchar not null,
+    day date not null default current_date,
+    count bigint not null default 1,
+    primary key (query, day)
+);
+
+CREATE INDEX search_trends_day_index on search_trends (day);
@@ -0,0 +1,4 @@
+drop table search_trend_users;
+drop table search_trend_salts;
+
+alter table search_trends drop column users;
@@ -0,0 +1,14 @@
+-- Rows counted before users were told apart are assumed to come from one user
+alter table search_trends add column users bigint not null default 1;
+
+create table search_trend_salts (
+    day date not null primary key,
+    salt varchar not null default md5(random()::text || clock_timestamp()::text)
+);
+
+create table search_trend_users (
+    query varchar not null,
+    day date not null,
+    user_hash varchar not null,
+    primary key (query, day, user_hash)
+);
@@ -0,0 +1,6 @@
+drop index sessions_user_id_index;
+
+alter table sessions drop column last_seen;
+alter table sessions drop column created_at;
+alter table sessions drop column ip;
+alter table sessions drop column user_agent;
@@ -0,0 +1,6 @@
+alter table sessions add column user_agent varchar not null default '';
+alter table sessions add column ip varchar not null default '';
+alter table sessions add column created_at timestamp not null default now();
+alter table sessions add column last_seen timestamp not null default now();
+
+CREATE INDEX sessions_user_id_index on sessions (user_id);
@@ -0,0 +1,5 @@
+drop index sessions_expires_index;
+
+alter table sessions alter column expires set default now() + interval '24 hours';
+alter table sessions drop column deadline;
+alter table sessions drop column remember;
@@ -0,0 +1,7 @@
+alter table sessions add column remember boolean not null default false;
+alter table sessions add column deadline timestamp;
+update sessions set deadline = expires;
+alter table sessions alter column deadline set not null;
+alter table sessions alter column expires drop default;
+
+CREATE INDEX sessions_expires_index on sessions (expires);
@@ -0,0 +1,7 @@
+drop table track_charts cascade;
+
+DROP INDEX IF EXISTS likes_track_created_at_index;
+DROP INDEX IF EXISTS favourites_track_created_at_index;
+DROP INDEX IF EXISTS plays_track_played_at_index;
+
+alter table tracks add column if not exists rating bigint not null default 0;
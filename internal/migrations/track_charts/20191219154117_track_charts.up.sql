@@ -0,0 +1,15 @@
+create table track_charts (
+    period varchar not null,
+    position bigint not null,
+    track_id bigint not null references tracks(id) on delete cascade,
+    score bigint not null default 0,
+    refreshed_at timestamp not null default now(),
+    primary key (period, position),
+    constraint FK_CHARTS_TO_TRACKS FOREIGN KEY (track_id) REFERENCES tracks(id)
+);
+
+CREATE INDEX likes_track_created_at_index on likes (track_id, created_at);
+CREATE INDEX favourites_track_created_at_index on favourites (track_id, created_at);
+CREATE INDEX plays_track_played_at_index on plays (track_id, played_at);
+
+alter table tracks drop column if exists rating;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS likes_created_at_index;
+DROP INDEX IF EXISTS favourites_created_at_index;
+DROP INDEX IF EXISTS plays_played_at_index;
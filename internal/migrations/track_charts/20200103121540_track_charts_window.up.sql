@@ -0,0 +1,3 @@
+CREATE INDEX likes_created_at_index on likes (created_at);
+CREATE INDEX favourites_created_at_index on favourites (created_at);
+CREATE INDEX plays_played_at_index on plays (played_at);
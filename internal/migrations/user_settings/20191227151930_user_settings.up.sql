@@ -0,0 +1,10 @@
+create table user_settings (
+    user_id bigint not null primary key references users(id) on delete cascade,
+    show_favourites boolean not null default true,
+    show_history boolean not null default false,
+    follow_policy int not null default 0,
+    notify_followers boolean not null default true,
+    notify_collaborations boolean not null default true,
+    notify_likes boolean not null default true,
+    updated_at timestamp not null default now()
+);
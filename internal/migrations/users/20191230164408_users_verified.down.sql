@@ -0,0 +1,2 @@
+alter table users drop column verification_sent_at;
+alter table users drop column verified;
@@ -0,0 +1,3 @@
+alter table users add column verified boolean not null default false;
+alter table users add column verification_sent_at timestamp;
+update users set verified = true;
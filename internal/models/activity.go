@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Activity is an event of the user's feed. Depending on the type
+// only one of Track, Playlist and Target is set.
+type Activity struct {
+	ID        uint64    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	User      *User     `json:"user"`
+	Track     *Track    `json:"track,omitempty"`
+	Playlist  *Playlist `json:"playlist,omitempty"`
+	Target    *User     `json:"target,omitempty"`
+}
@@ -0,0 +1,7 @@
+package models
+
+// Event is pushed to connected clients over WebSocket.
+type Event struct {
+	Type string      `json:"type"`
+	Body interface{} `json:"body"`
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type HistoryItem struct {
+	ID       uint64    `json:"id"`
+	PlayedAt time.Time `json:"played_at"`
+	Track    *Track    `json:"track"`
+}
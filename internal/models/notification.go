@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Notification tells the user about an action of another user (the actor),
+// Playlist is set for collaboration invitations only.
+type Notification struct {
+	ID        uint64    `json:"id"`
+	Type      string    `json:"type"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+	Actor     *User     `json:"actor"`
+	Playlist  *Playlist `json:"playlist,omitempty"`
+}
@@ -1,11 +1,22 @@
 package models
 
 type Playlist struct {
-	ID            uint64 `json:"id"`
-	OwnerID       uint64 `json:"owner_id"`
-	Name          string `json:"name"`
-	Description   string `json:"description"`
-	Photo         string `json:"photo"`
+	ID              uint64 `json:"id"`
+	OwnerID         uint64 `json:"owner_id"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	Photo           string `json:"photo"`
+	CustomPhoto     bool   `json:"-"`
+	AllowDuplicates bool   `json:"allow_duplicates"`
+	Visibility      int8   `json:"visibility"` // 0 - private; 1 - public; 2 - unlisted;
+	Link            string `json:"link,omitempty"`
+}
+
+type Collaborator struct {
+	UserID   uint64 `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+	Accepted bool   `json:"accepted"`
 }
 
 func NewPlaylist(name string, description string, ownerID uint64) *Playlist {
@@ -0,0 +1,52 @@
+package models
+
+import (
+	. "2019_2_Covenant/tools/vars"
+	"time"
+)
+
+type SearchQuery struct {
+	ID         uint64    `json:"id"`
+	Query      string    `json:"query"`
+	SearchedAt time.Time `json:"searched_at"`
+}
+
+type TrendingQuery struct {
+	Query string `json:"query"`
+	Count uint64 `json:"count"`
+}
+
+// SearchFilter narrows search results. Zero values mean "not set".
+// Year bounds apply to tracks and albums, duration bounds (in seconds)
+// and favourites only to tracks.
+type SearchFilter struct {
+	YearFrom     uint64
+	YearTo       uint64
+	DurationFrom uint64
+	DurationTo   uint64
+	Favourites   bool
+}
+
+// AppliesTo tells whether every filter set can narrow results of the type
+func (f *SearchFilter) AppliesTo(searchType string) bool {
+	years := f.YearFrom != 0 || f.YearTo != 0
+	tracksOnly := f.DurationFrom != 0 || f.DurationTo != 0 || f.Favourites
+
+	switch searchType {
+	case SEARCH_TRACK:
+		return true
+	case SEARCH_ALBUM:
+		return !tracksOnly
+	}
+
+	return !years && !tracksOnly
+}
+
+type SearchResult struct {
+	Tracks    []*Track
+	Albums    []*Album
+	Artists   []*Artist
+	Users     []*User
+	Playlists []*Playlist
+	Facets    map[string]uint64
+}
@@ -14,24 +14,54 @@ import (
 )
 
 type Session struct {
-	ID      uint64
-	UserID  uint64
-	Expires time.Time
-	Data    string
+	ID        uint64    `json:"id"`
+	UserID    uint64    `json:"-"`
+	Expires   time.Time `json:"expires"`
+	Deadline  time.Time `json:"-"`
+	Remember  bool      `json:"remember"`
+	Data      string    `json:"-"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	Current   bool      `json:"current"`
 }
 
-func NewSession(userID uint64) (*Session, *http.Cookie) {
+// NewSession creates a session, its expiry is set when it is stored.
+func NewSession(userID uint64, userAgent string, ip string, remember bool) *Session {
+	return &Session{
+		UserID:    userID,
+		Data:      uuid.New().String(),
+		Remember:  remember,
+		UserAgent: userAgent,
+		IP:        ip,
+	}
+}
+
+// Cookie of a remembered session outlives the browser, the others
+// are dropped when it is closed.
+func (s *Session) Cookie() *http.Cookie {
 	cookie := &http.Cookie{
-		Name:    "Covenant",
-		Value:   uuid.New().String(),
-		Expires: time.Now().Add(24 * time.Hour),
+		Name:  "Covenant",
+		Value: s.Data,
+		Path:  "/",
 	}
 
-	return &Session{
-		UserID:  userID,
-		Data:    cookie.Value,
-		Expires: cookie.Expires,
-	}, cookie
+	if s.Remember {
+		cookie.Expires = s.Expires
+	}
+
+	return cookie
+}
+
+// ExpiredCookie makes the browser forget the session cookie.
+func (s *Session) ExpiredCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:    "Covenant",
+		Value:   s.Data,
+		Path:    "/",
+		Expires: time.Now().AddDate(0, 0, -1),
+	}
 }
 
 type CSRFTokenManager struct {
@@ -0,0 +1,13 @@
+package models
+
+// Settings of a user are stored apart from the account except for access,
+// which lives in users table. Users without a stored row get the defaults.
+type Settings struct {
+	Access               int8 `json:"access"` // 0 - public; 1 - private;
+	ShowFavourites       bool `json:"show_favourites"`
+	ShowHistory          bool `json:"show_history"`
+	FollowPolicy         int8 `json:"follow_policy"` // 0 - everyone; 1 - nobody;
+	NotifyFollowers      bool `json:"notify_followers"`
+	NotifyCollaborations bool `json:"notify_collaborations"`
+	NotifyLikes          bool `json:"notify_likes"`
+}
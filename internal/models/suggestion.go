@@ -0,0 +1,9 @@
+package models
+
+type Suggestion struct {
+	Type     string `json:"type"` // track, album, artist or user
+	ID       uint64 `json:"id"`
+	Text     string `json:"text"`
+	Subtitle string `json:"subtitle,omitempty"`
+	Photo    string `json:"photo,omitempty"`
+}
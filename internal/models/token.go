@@ -0,0 +1,39 @@
+package models
+
+import (
+	. "2019_2_Covenant/tools/vars"
+	"time"
+)
+
+type Token struct {
+	ID        uint64     `json:"id"`
+	UserID    uint64     `json:"-"`
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	LastUsed  *time.Time `json:"last_used"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func NewToken(userID uint64, name string, scope string) *Token {
+	return &Token{
+		UserID: userID,
+		Name:   name,
+		Scope:  scope,
+	}
+}
+
+var scopeRanks = map[string]int{
+	TOKEN_SCOPE_READ_ONLY:     1,
+	TOKEN_SCOPE_LIBRARY_WRITE: 2,
+	TOKEN_SCOPE_ADMIN:         3,
+}
+
+func ValidScope(scope string) bool {
+	_, ok := scopeRanks[scope]
+	return ok
+}
+
+// Allows reports whether the token scope includes the given one.
+func (t *Token) Allows(scope string) bool {
+	return scopeRanks[t.Scope] >= scopeRanks[scope] && ValidScope(scope)
+}
@@ -15,7 +15,9 @@ type User struct {
 	Avatar        string `json:"avatar"`
 	Role          int8   `json:"role"`   // 0 - user; 1 - admin;
 	Access        int8   `json:"access"` // 0 - public; 1 - private;
+	Verified      bool   `json:"verified"`
 	Subscription  *bool  `json:"subscription,omitempty"`
+	Requested     *bool  `json:"requested,omitempty"`
 }
 
 func NewUser(email string, nickname string, plainPassword string) *User {
@@ -0,0 +1,185 @@
+package delivery
+
+import (
+	"2019_2_Covenant/internal/middlewares"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/notifications"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/reader"
+	. "2019_2_Covenant/tools/base_handler"
+	. "2019_2_Covenant/tools/response"
+	. "2019_2_Covenant/tools/vars"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+type NotificationsHandler struct {
+	BaseHandler
+	NUsecase notifications.Usecase
+}
+
+func NewNotificationsHandler(nUC notifications.Usecase,
+	mManager *middlewares.MiddlewareManager,
+	logger *logger.LogrusLogger) *NotificationsHandler {
+	return &NotificationsHandler{
+		BaseHandler: BaseHandler{
+			MManager:  mManager,
+			Logger:    logger,
+			ReqReader: reader.NewReqReader(),
+		},
+		NUsecase: nUC,
+	}
+}
+
+func (nh *NotificationsHandler) Configure(e *echo.Echo) {
+	e.GET("/api/v1/notifications", nh.GetNotifications(), nh.MManager.CheckAuthStrictly)
+	e.GET("/api/v1/notifications/unread", nh.GetUnreadCount(), nh.MManager.CheckAuthStrictly)
+	e.POST("/api/v1/notifications/read", nh.MarkAllRead(), nh.MManager.CheckAuthStrictly)
+	e.POST("/api/v1/notifications/:id/read", nh.MarkRead(), nh.MManager.CheckAuthStrictly)
+}
+
+func (nh *NotificationsHandler) GetNotifications() echo.HandlerFunc {
+	type Request struct {
+		Count  uint64 `query:"count" validate:"required"`
+		Offset uint64 `query:"offset"`
+	}
+
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			nh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := nh.ReqReader.Read(c, request, nil); err != nil {
+			nh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		items, total, err := nh.NUsecase.Fetch(sess.UserID, request.Count, request.Offset)
+
+		if err != nil {
+			nh.Logger.Log(c, "error", "Error while fetching notifications.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		unread, err := nh.NUsecase.CountUnread(sess.UserID)
+
+		if err != nil {
+			nh.Logger.Log(c, "error", "Error while counting unread notifications.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"notifications": items,
+				"total":         total,
+				"unread":        unread,
+			},
+		})
+	}
+}
+
+func (nh *NotificationsHandler) GetUnreadCount() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			nh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		unread, err := nh.NUsecase.CountUnread(sess.UserID)
+
+		if err != nil {
+			nh.Logger.Log(c, "error", "Error while counting unread notifications.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"unread": unread,
+			},
+		})
+	}
+}
+
+func (nh *NotificationsHandler) MarkRead() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			nh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		nID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			nh.Logger.Log(c, "info", "Atoi error.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		if err := nh.NUsecase.MarkRead(sess.UserID, uint64(nID)); err != nil {
+			if err == ErrNotFound {
+				nh.Logger.Log(c, "info", "Notification not found.", nID)
+				return c.JSON(http.StatusNotFound, Response{
+					Error: err.Error(),
+				})
+			}
+
+			nh.Logger.Log(c, "error", "Error while marking notification as read.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (nh *NotificationsHandler) MarkAllRead() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			nh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := nh.NUsecase.MarkAllRead(sess.UserID); err != nil {
+			nh.Logger.Log(c, "error", "Error while marking notifications as read.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
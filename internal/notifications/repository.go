@@ -0,0 +1,12 @@
+package notifications
+
+import "2019_2_Covenant/internal/models"
+
+type Repository interface {
+	Store(userID uint64, kind string, actorID uint64, playlistID uint64) (uint64, error)
+	Remove(userID uint64, kind string, actorID uint64, playlistID uint64) error
+	Fetch(userID uint64, count uint64, offset uint64) ([]*models.Notification, uint64, error)
+	CountUnread(userID uint64) (uint64, error)
+	MarkRead(userID uint64, notificationID uint64) error
+	MarkAllRead(userID uint64) error
+}
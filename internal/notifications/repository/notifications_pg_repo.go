@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/notifications"
+	. "2019_2_Covenant/tools/vars"
+	"database/sql"
+	"fmt"
+)
+
+// Column of user_settings table that turns each kind of notification on
+var settingColumns = map[string]string{
+	NOTIFICATION_FOLLOW:           "notify_followers",
+	NOTIFICATION_FOLLOW_REQUEST:   "notify_followers",
+	NOTIFICATION_REQUEST_ACCEPTED: "notify_followers",
+	NOTIFICATION_COLLABORATION:    "notify_collaborations",
+	NOTIFICATION_LIKE:             "notify_likes",
+}
+
+type NotificationsRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationsRepository(db *sql.DB) notifications.Repository {
+	return &NotificationsRepository{
+		db: db,
+	}
+}
+
+// Store adds the notification unless the user turned this kind of
+// notifications off or blocked the actor, in which case the returned id
+// is 0; playlistID is 0 for notifications not about a playlist.
+func (nR *NotificationsRepository) Store(userID uint64, kind string, actorID uint64, playlistID uint64) (uint64, error) {
+	var id uint64
+
+	column, ok := settingColumns[kind]
+
+	if !ok {
+		return 0, ErrBadParam
+	}
+
+	if err := nR.db.QueryRow(fmt.Sprintf("INSERT INTO notifications (user_id, type, actor_id, playlist_id) "+
+		"SELECT $1, $2, $3, nullif($4::bigint, 0) "+
+		"WHERE coalesce((SELECT %s FROM user_settings WHERE user_id = $1), true) "+
+		"AND NOT EXISTS (SELECT 1 FROM blocks WHERE user_id = $1 AND blocked_id = $3) RETURNING id", column),
+		userID,
+		kind,
+		actorID,
+		playlistID,
+	).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Remove deletes notifications which are no longer relevant, e.g. about
+// a follow request that was cancelled; playlistID is 0 for notifications
+// not about a playlist.
+func (nR *NotificationsRepository) Remove(userID uint64, kind string, actorID uint64, playlistID uint64) error {
+	if _, err := nR.db.Exec("DELETE FROM notifications WHERE user_id = $1 AND type = $2 AND actor_id = $3 "+
+		"AND playlist_id IS NOT DISTINCT FROM nullif($4::bigint, 0)",
+		userID,
+		kind,
+		actorID,
+		playlistID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (nR *NotificationsRepository) Fetch(userID uint64, count uint64, offset uint64) ([]*models.Notification, uint64, error) {
+	var items []*models.Notification
+	var total uint64
+
+	if err := nR.db.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = $1",
+		userID,
+	).Scan(&total); err != nil {
+		return nil, total, err
+	}
+
+	rows, err := nR.db.Query("SELECT N.id, N.type, N.read, N.created_at, U.id, U.nickname, U.avatar, "+
+		"P.id, P.owner_id, P.name, P.photo FROM notifications N "+
+		"JOIN users U ON U.id = N.actor_id "+
+		"LEFT JOIN playlists P ON P.id = N.playlist_id "+
+		"WHERE N.user_id = $1 ORDER BY N.id DESC LIMIT $2 OFFSET $3",
+		userID,
+		count,
+		offset,
+	)
+
+	if err != nil {
+		return nil, total, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		n := &models.Notification{Actor: &models.User{}}
+
+		var playlistID, ownerID sql.NullInt64
+		var playlistName, playlistPhoto sql.NullString
+
+		if err := rows.Scan(&n.ID, &n.Type, &n.Read, &n.CreatedAt, &n.Actor.ID, &n.Actor.Nickname, &n.Actor.Avatar,
+			&playlistID, &ownerID, &playlistName, &playlistPhoto,
+		); err != nil {
+			return nil, total, err
+		}
+
+		if playlistID.Valid {
+			n.Playlist = &models.Playlist{
+				ID:      uint64(playlistID.Int64),
+				OwnerID: uint64(ownerID.Int64),
+				Name:    playlistName.String,
+				Photo:   playlistPhoto.String,
+			}
+		}
+
+		items = append(items, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, total, err
+	}
+
+	return items, total, nil
+}
+
+func (nR *NotificationsRepository) CountUnread(userID uint64) (uint64, error) {
+	var unread uint64
+
+	if err := nR.db.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND NOT read",
+		userID,
+	).Scan(&unread); err != nil {
+		return 0, err
+	}
+
+	return unread, nil
+}
+
+func (nR *NotificationsRepository) MarkRead(userID uint64, notificationID uint64) error {
+	res, err := nR.db.Exec("UPDATE notifications SET read = true WHERE id = $1 AND user_id = $2",
+		notificationID,
+		userID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (nR *NotificationsRepository) MarkAllRead(userID uint64) error {
+	if _, err := nR.db.Exec("UPDATE notifications SET read = true WHERE user_id = $1 AND NOT read",
+		userID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package notifications
+
+import "2019_2_Covenant/internal/models"
+
+type Usecase interface {
+	Fetch(userID uint64, count uint64, offset uint64) ([]*models.Notification, uint64, error)
+	CountUnread(userID uint64) (uint64, error)
+	MarkRead(userID uint64, notificationID uint64) error
+	MarkAllRead(userID uint64) error
+}
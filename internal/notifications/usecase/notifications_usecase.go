@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/notifications"
+	. "2019_2_Covenant/tools/vars"
+)
+
+type NotificationsUsecase struct {
+	notificationsRepo notifications.Repository
+}
+
+func NewNotificationsUsecase(repo notifications.Repository) notifications.Usecase {
+	return &NotificationsUsecase{
+		notificationsRepo: repo,
+	}
+}
+
+func (nUC *NotificationsUsecase) Fetch(userID uint64, count uint64, offset uint64) ([]*models.Notification, uint64, error) {
+	items, total, err := nUC.notificationsRepo.Fetch(userID, count, offset)
+
+	if err != nil {
+		return nil, total, err
+	}
+
+	if items == nil {
+		items = []*models.Notification{}
+	}
+
+	return items, total, nil
+}
+
+func (nUC *NotificationsUsecase) CountUnread(userID uint64) (uint64, error) {
+	return nUC.notificationsRepo.CountUnread(userID)
+}
+
+func (nUC *NotificationsUsecase) MarkRead(userID uint64, notificationID uint64) error {
+	err := nUC.notificationsRepo.MarkRead(userID, notificationID)
+
+	if err == ErrNotFound {
+		return err
+	}
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (nUC *NotificationsUsecase) MarkAllRead(userID uint64) error {
+	if err := nUC.notificationsRepo.MarkAllRead(userID); err != nil {
+		return ErrInternalServerError
+	}
+
+	return nil
+}
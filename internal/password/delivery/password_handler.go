@@ -0,0 +1,106 @@
+package delivery
+
+import (
+	"2019_2_Covenant/internal/middlewares"
+	"2019_2_Covenant/internal/password"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/reader"
+	. "2019_2_Covenant/tools/base_handler"
+	. "2019_2_Covenant/tools/response"
+	. "2019_2_Covenant/tools/vars"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+type PasswordHandler struct {
+	BaseHandler
+	PUsecase password.Usecase
+}
+
+func NewPasswordHandler(pUC password.Usecase,
+	mManager *middlewares.MiddlewareManager,
+	logger *logger.LogrusLogger) *PasswordHandler {
+	return &PasswordHandler{
+		BaseHandler: BaseHandler{
+			MManager:  mManager,
+			Logger:    logger,
+			ReqReader: reader.NewReqReader(),
+		},
+		PUsecase: pUC,
+	}
+}
+
+func (ph *PasswordHandler) Configure(e *echo.Echo) {
+	e.POST("/api/v1/password/forgot", ph.Forgot())
+	e.POST("/api/v1/password/reset", ph.Reset())
+}
+
+func (ph *PasswordHandler) Forgot() echo.HandlerFunc {
+	type Request struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	return func(c echo.Context) error {
+		request := &Request{}
+
+		if err := ph.ReqReader.Read(c, request, nil); err != nil {
+			ph.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if err := ph.PUsecase.Forgot(request.Email); err != nil {
+			ph.Logger.Log(c, "error", "Error while issuing password reset token.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (ph *PasswordHandler) Reset() echo.HandlerFunc {
+	type Request struct {
+		Token            string `json:"token" validate:"required"`
+		Password         string `json:"password" validate:"required,gte=6"`
+		PassConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
+	}
+
+	correctData := func(req interface{}) bool {
+		return strings.Contains(req.(*Request).Password, " ") == false
+	}
+
+	return func(c echo.Context) error {
+		request := &Request{}
+
+		if err := ph.ReqReader.Read(c, request, correctData); err != nil {
+			ph.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if err := ph.PUsecase.Reset(request.Token, request.Password); err != nil {
+			if err == ErrBadToken {
+				ph.Logger.Log(c, "info", "Bad password reset token.")
+				return c.JSON(http.StatusBadRequest, Response{
+					Error: err.Error(),
+				})
+			}
+
+			ph.Logger.Log(c, "error", "Error while resetting password.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package password
+
+import "time"
+
+type Repository interface {
+	MarkSent(userID uint64, interval time.Duration) error
+	StoreToken(userID uint64, tokenHash string, expires time.Time) error
+	Reset(tokenHash string, password string) (uint64, error)
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"2019_2_Covenant/internal/password"
+	. "2019_2_Covenant/tools/vars"
+	"database/sql"
+	"time"
+)
+
+type PasswordRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordRepository(db *sql.DB) password.Repository {
+	return &PasswordRepository{
+		db: db,
+	}
+}
+
+// MarkSent records that a reset letter is being sent to the user.
+// ErrTooManyRequests is returned if the previous letter was sent less
+// than interval ago.
+func (pR *PasswordRepository) MarkSent(userID uint64, interval time.Duration) error {
+	if err := pR.db.QueryRow("UPDATE users SET password_reset_sent_at = now() "+
+		"WHERE id = $1 "+
+		"AND (password_reset_sent_at IS NULL OR password_reset_sent_at < now() - $2 * interval '1 second') "+
+		"RETURNING id",
+		userID,
+		interval.Seconds(),
+	).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrTooManyRequests
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// StoreToken saves the hash of a new reset token, dropping the unused
+// tokens issued to the user before, so that only the latest letter works.
+func (pR *PasswordRepository) StoreToken(userID uint64, tokenHash string, expires time.Time) error {
+	tx, err := pR.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM password_resets WHERE user_id = $1 AND used_at IS NULL",
+		userID,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO password_resets (user_id, token_hash, expires) VALUES ($1, $2, $3)",
+		userID,
+		tokenHash,
+		expires,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Reset uses up the token, sets the new password of its owner and ends
+// all the owner's sessions and API tokens. The owner's id is returned.
+// ErrBadToken is returned if the token is unknown, expired or was already used.
+func (pR *PasswordRepository) Reset(tokenHash string, password string) (uint64, error) {
+	var userID uint64
+
+	tx, err := pR.db.Begin()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.QueryRow("UPDATE password_resets SET used_at = now() "+
+		"WHERE token_hash = $1 AND used_at IS NULL AND expires > now() RETURNING user_id",
+		tokenHash,
+	).Scan(&userID); err != nil {
+		tx.Rollback()
+
+		if err == sql.ErrNoRows {
+			return 0, ErrBadToken
+		}
+
+		return 0, err
+	}
+
+	if _, err := tx.Exec("UPDATE users SET password = $1 WHERE id = $2",
+		password,
+		userID,
+	); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM sessions WHERE user_id = $1",
+		userID,
+	); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM api_tokens WHERE user_id = $1",
+		userID,
+	); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
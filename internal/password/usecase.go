@@ -0,0 +1,6 @@
+package password
+
+type Usecase interface {
+	Forgot(email string) error
+	Reset(token string, password string) error
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/password"
+	"2019_2_Covenant/internal/realtime"
+	"2019_2_Covenant/internal/user"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/mailer"
+	. "2019_2_Covenant/tools/vars"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+type PasswordUsecase struct {
+	passwordRepo   password.Repository
+	userRepo       user.Repository
+	mailer         mailer.Mailer
+	disconnector   realtime.Disconnector
+	logger         *logger.LogrusLogger
+	resetURL       string
+	tokenTTL       time.Duration
+	resendInterval time.Duration
+}
+
+// NewPasswordUsecase creates the usecase mailing links of the form
+// resetURL?token=... which stay valid for tokenTTL, at most one letter per
+// resendInterval. Realtime connections of users who reset their passwords
+// are closed through the disconnector.
+func NewPasswordUsecase(repo password.Repository, uRepo user.Repository, m mailer.Mailer,
+	d realtime.Disconnector, resetURL string, tokenTTL time.Duration, resendInterval time.Duration,
+	logger *logger.LogrusLogger) password.Usecase {
+	return &PasswordUsecase{
+		passwordRepo:   repo,
+		userRepo:       uRepo,
+		mailer:         m,
+		disconnector:   d,
+		logger:         logger,
+		resetURL:       resetURL,
+		tokenTTL:       tokenTTL,
+		resendInterval: resendInterval,
+	}
+}
+
+// Forgot mails a reset link to the user with the email. It succeeds for
+// unknown emails and throttled requests too, not to reveal who is registered.
+func (pUC *PasswordUsecase) Forgot(email string) error {
+	usr, err := pUC.userRepo.GetByEmail(email)
+
+	if err != nil {
+		if err == ErrNotFound {
+			return nil
+		}
+
+		return ErrInternalServerError
+	}
+
+	if err := pUC.passwordRepo.MarkSent(usr.ID, pUC.resendInterval); err != nil {
+		if err == ErrTooManyRequests {
+			return nil
+		}
+
+		return ErrInternalServerError
+	}
+
+	token, err := newToken()
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	if err := pUC.passwordRepo.StoreToken(usr.ID, hashToken(token), time.Now().Add(pUC.tokenTTL)); err != nil {
+		return ErrInternalServerError
+	}
+
+	body := fmt.Sprintf("Hi, %s!\n\n"+
+		"To set a new password for your Covenant account follow the link:\n%s?token=%s\n\n"+
+		"The link is valid for %s. If you did not ask for it, just ignore this letter.\n",
+		usr.Nickname, pUC.resetURL, token, pUC.tokenTTL)
+
+	go func() {
+		if err := pUC.mailer.Send(usr.Email, "Password reset", body); err != nil {
+			pUC.logger.L.Error(err)
+		}
+	}()
+
+	return nil
+}
+
+func (pUC *PasswordUsecase) Reset(token string, plainPassword string) error {
+	password, err := models.EncryptPassword(plainPassword)
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	userID, err := pUC.passwordRepo.Reset(hashToken(token), password)
+
+	if err != nil {
+		if err == ErrBadToken {
+			return err
+		}
+
+		return ErrInternalServerError
+	}
+
+	pUC.disconnector.CloseUser(userID)
+
+	return nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Only hashes of the tokens are stored, so a leaked table can't be used
+// to take over accounts.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
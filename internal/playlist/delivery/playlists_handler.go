@@ -4,23 +4,34 @@ import (
 	"2019_2_Covenant/internal/middlewares"
 	"2019_2_Covenant/internal/models"
 	"2019_2_Covenant/internal/playlist"
+	"2019_2_Covenant/internal/track"
 	"2019_2_Covenant/pkg/logger"
 	"2019_2_Covenant/pkg/reader"
 	. "2019_2_Covenant/tools/base_handler"
+	"2019_2_Covenant/tools/playlist_format"
 	. "2019_2_Covenant/tools/response"
 	"2019_2_Covenant/tools/time_parser"
 	. "2019_2_Covenant/tools/vars"
+	"fmt"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 type PlaylistHandler struct {
 	BaseHandler
 	PUsecase playlist.Usecase
+	TUsecase track.Usecase
 }
 
 func NewPlaylistHandler(pUC playlist.Usecase,
+	tUC track.Usecase,
 	mManager *middlewares.MiddlewareManager,
 	logger *logger.LogrusLogger) *PlaylistHandler {
 	return &PlaylistHandler{
@@ -30,30 +41,53 @@ func NewPlaylistHandler(pUC playlist.Usecase,
 			ReqReader: reader.NewReqReader(),
 		},
 		PUsecase: pUC,
+		TUsecase: tUC,
 	}
 }
 
 func (ph *PlaylistHandler) Configure(e *echo.Echo) {
-	e.POST("/api/v1/playlists", ph.CreatePlaylist(), ph.MManager.CheckAuthStrictly)
+	write := ph.MManager.TokenScope(TOKEN_SCOPE_LIBRARY_WRITE)
+
+	e.POST("/api/v1/playlists", ph.CreatePlaylist(), write, ph.MManager.CheckAuthStrictly)
 	e.GET("/api/v1/playlists", ph.GetPlaylists(), ph.MManager.CheckAuthStrictly)
-	e.GET("/api/v1/playlists/:id", ph.GetSinglePlaylist(), ph.MManager.CheckAuthStrictly)
-	e.GET("/api/v1/playlists/:id/tracks", ph.GetTracksFromPlaylist(), ph.MManager.CheckAuthStrictly)
-	e.DELETE("/api/v1/playlists/:id", ph.DeletePlaylist(), ph.MManager.CheckAuthStrictly)
-	e.POST("/api/v1/playlists/:id/tracks", ph.AddToPlaylist(), ph.MManager.CheckAuthStrictly)
-	e.DELETE("/api/v1/playlists/:playlist_id/tracks/:track_id", ph.RemoveFromPlaylist(), ph.MManager.CheckAuthStrictly)
+	e.GET("/api/v1/playlists/invitations", ph.GetInvitations(), ph.MManager.CheckAuthStrictly)
+	e.POST("/api/v1/playlists/import", ph.ImportPlaylist(), write, ph.MManager.CheckAuthStrictly)
+	e.GET("/api/v1/playlists/shared/:link", ph.GetSharedPlaylist(), ph.MManager.CheckAuth)
+	e.GET("/api/v1/playlists/shared/:link/tracks", ph.GetTracksFromSharedPlaylist(), ph.MManager.CheckAuth)
+	e.GET("/api/v1/playlists/:id", ph.GetSinglePlaylist(), ph.MManager.CheckAuth)
+	e.GET("/api/v1/playlists/:id/tracks", ph.GetTracksFromPlaylist(), ph.MManager.CheckAuth)
+	e.GET("/api/v1/playlists/:id/export", ph.ExportPlaylist(), ph.MManager.CheckAuth)
+	e.DELETE("/api/v1/playlists/:id", ph.DeletePlaylist(), write, ph.MManager.CheckAuthStrictly)
+	e.PUT("/api/v1/playlists/:id", ph.UpdatePlaylist(), write, ph.MManager.CheckAuthStrictly)
+	e.PUT("/api/v1/playlists/:id/photo", ph.UploadPlaylistPhoto(), write, ph.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/playlists/:id/photo", ph.ResetPlaylistPhoto(), write, ph.MManager.CheckAuthStrictly)
+	e.POST("/api/v1/playlists/:id/tracks", ph.AddToPlaylist(), write, ph.MManager.CheckAuthStrictly)
+	e.PATCH("/api/v1/playlists/:id/tracks", ph.MoveTracks(), write, ph.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/playlists/:playlist_id/tracks/:track_id", ph.RemoveFromPlaylist(), write, ph.MManager.CheckAuthStrictly)
+
+	e.POST("/api/v1/playlists/:id/likes", ph.LikePlaylist(), write, ph.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/playlists/:id/likes", ph.UnlikePlaylist(), write, ph.MManager.CheckAuthStrictly)
+
+	e.GET("/api/v1/playlists/:id/collaborators", ph.GetCollaborators(), ph.MManager.CheckAuth)
+	e.POST("/api/v1/playlists/:id/collaborators", ph.InviteCollaborator(), write, ph.MManager.CheckAuthStrictly,
+		ph.MManager.CheckVerified(RESTRICT_COLLABORATION))
+	e.POST("/api/v1/playlists/:id/collaborators/accept", ph.AcceptCollaboration(), write, ph.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/playlists/:playlist_id/collaborators/:user_id", ph.RemoveCollaborator(), write, ph.MManager.CheckAuthStrictly)
 }
 
 func (ph *PlaylistHandler) CreatePlaylist() echo.HandlerFunc {
 	type Request struct {
-		Name        string `json:"name" validate:"required"`
-		Description string `json:"description"`
+		Name            string `json:"name" validate:"required"`
+		Description     string `json:"description"`
+		AllowDuplicates bool   `json:"allow_duplicates"`
+		Visibility      int8   `json:"visibility" validate:"min=0,max=2"`
 	}
 
 	return func(c echo.Context) error {
-		sess, ok := c.Get("session").(*models.Session)
+		usr, ok := c.Get("user").(*models.User)
 
 		if !ok {
-			ph.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
 			return c.JSON(http.StatusInternalServerError, Response{
 				Error: ErrInternalServerError.Error(),
 			})
@@ -68,7 +102,16 @@ func (ph *PlaylistHandler) CreatePlaylist() echo.HandlerFunc {
 			})
 		}
 
-		newPlaylist := models.NewPlaylist(request.Name, request.Description, sess.UserID)
+		if request.Visibility == PLAYLIST_PUBLIC && ph.MManager.Restricted(usr, RESTRICT_PUBLIC_PLAYLISTS) {
+			ph.Logger.Log(c, "info", "Public playlist by unverified user.", usr.ID)
+			return c.JSON(http.StatusForbidden, Response{
+				Error: ErrNotVerified.Error(),
+			})
+		}
+
+		newPlaylist := models.NewPlaylist(request.Name, request.Description, usr.ID)
+		newPlaylist.AllowDuplicates = request.AllowDuplicates
+		newPlaylist.Visibility = request.Visibility
 
 		if err := ph.PUsecase.Store(newPlaylist); err != nil {
 			ph.Logger.Log(c, "info", "Error while storing playlist.", err.Error())
@@ -92,10 +135,10 @@ func (ph *PlaylistHandler) GetPlaylists() echo.HandlerFunc {
 	}
 
 	return func(c echo.Context) error {
-		sess, ok := c.Get("session").(*models.Session)
+		usr, ok := c.Get("user").(*models.User)
 
 		if !ok {
-			ph.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
 			return c.JSON(http.StatusInternalServerError, Response{
 				Error: ErrInternalServerError.Error(),
 			})
@@ -110,7 +153,7 @@ func (ph *PlaylistHandler) GetPlaylists() echo.HandlerFunc {
 			})
 		}
 
-		playlists, total, err := ph.PUsecase.Fetch(sess.UserID, request.Count, request.Offset)
+		playlists, total, err := ph.PUsecase.Fetch(usr.ID, usr, request.Count, request.Offset)
 
 		if err != nil {
 			ph.Logger.Log(c, "error", "Error while fetching playlists.", err)
@@ -122,7 +165,7 @@ func (ph *PlaylistHandler) GetPlaylists() echo.HandlerFunc {
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
 				"playlists": playlists,
-				"total":  total,
+				"total":     total,
 			},
 		})
 	}
@@ -130,6 +173,15 @@ func (ph *PlaylistHandler) GetPlaylists() echo.HandlerFunc {
 
 func (ph *PlaylistHandler) DeletePlaylist() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
 		pID, err := strconv.Atoi(c.Param("id"))
 
 		if err != nil {
@@ -139,9 +191,204 @@ func (ph *PlaylistHandler) DeletePlaylist() echo.HandlerFunc {
 			})
 		}
 
-		if err := ph.PUsecase.DeleteByID(uint64(pID)); err != nil {
+		if err := ph.PUsecase.DeleteByID(uint64(pID), usr); err != nil {
 			ph.Logger.Log(c, "info", "Error while remove playlist.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+// UpdatePlaylist changes the name and only those of the other fields present in the request
+func (ph *PlaylistHandler) UpdatePlaylist() echo.HandlerFunc {
+	type Request struct {
+		Name            string  `json:"name" validate:"required"`
+		Description     *string `json:"description"`
+		AllowDuplicates *bool   `json:"allow_duplicates"`
+		Visibility      *int8   `json:"visibility" validate:"omitempty,min=0,max=2"`
+	}
+
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		pID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Atoi error.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := ph.ReqReader.Read(c, request, nil); err != nil {
+			ph.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if request.Visibility != nil && *request.Visibility == PLAYLIST_PUBLIC &&
+			ph.MManager.Restricted(usr, RESTRICT_PUBLIC_PLAYLISTS) {
+			ph.Logger.Log(c, "info", "Public playlist by unverified user.", usr.ID)
+			return c.JSON(http.StatusForbidden, Response{
+				Error: ErrNotVerified.Error(),
+			})
+		}
+
+		p, _, err := ph.PUsecase.GetSinglePlaylist(uint64(pID), usr)
+
+		if err != nil {
+			ph.Logger.Log(c, "info", "Error while getting playlist.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		p.Name = request.Name
+		if request.Description != nil {
+			p.Description = *request.Description
+		}
+		if request.AllowDuplicates != nil {
+			p.AllowDuplicates = *request.AllowDuplicates
+		}
+		if request.Visibility != nil {
+			p.Visibility = *request.Visibility
+		}
+
+		if err := ph.PUsecase.Update(p, usr); err != nil {
+			ph.Logger.Log(c, "info", "Error while updating playlist.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"playlist": p,
+			},
+		})
+	}
+}
+
+func (ph *PlaylistHandler) UploadPlaylistPhoto() echo.HandlerFunc {
+	rootPath, _ := os.Getwd()
+
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		pID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Atoi error.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := ph.PUsecase.CanManage(uint64(pID), usr); err != nil {
+			ph.Logger.Log(c, "info", "Can't change playlist photo.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			ph.Logger.Log(c, "info", "Can't extract file from request.", err)
 			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrRetrievingError.Error(),
+			})
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			ph.Logger.Log(c, "error", "Can't open file.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		defer src.Close()
+
+		filePath := fmt.Sprintf("%s%s-%s", PLAYLISTS_PHOTOS_PATH, uuid.New().String(), filepath.Base(file.Filename))
+		absolutePath := filepath.Join(rootPath, filePath)
+
+		dest, err := os.Create(absolutePath)
+		if err != nil {
+			ph.Logger.Log(c, "error", "Can't create file.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		defer dest.Close()
+
+		if _, err = io.Copy(dest, src); err != nil {
+			ph.Logger.Log(c, "error", "Can't copy file.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := ph.PUsecase.UpdatePhoto(uint64(pID), filePath, usr); err != nil {
+			os.Remove(absolutePath)
+
+			ph.Logger.Log(c, "info", "Error while storing photo in db.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (ph *PlaylistHandler) ResetPlaylistPhoto() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		pID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Atoi error.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := ph.PUsecase.ResetPhoto(uint64(pID), usr); err != nil {
+			ph.Logger.Log(c, "info", "Error while resetting playlist photo.", err)
+			return c.JSON(errorStatus(err), Response{
 				Error: err.Error(),
 			})
 		}
@@ -154,10 +401,20 @@ func (ph *PlaylistHandler) DeletePlaylist() echo.HandlerFunc {
 
 func (ph *PlaylistHandler) AddToPlaylist() echo.HandlerFunc {
 	type Request struct {
-		TrackID uint64 `json:"track_id" validate:"required"`
+		TrackID  uint64  `json:"track_id" validate:"required"`
+		Position *uint64 `json:"position"`
 	}
 
 	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
 		pID, err := strconv.Atoi(c.Param("id"))
 
 		if err != nil {
@@ -176,10 +433,76 @@ func (ph *PlaylistHandler) AddToPlaylist() echo.HandlerFunc {
 			})
 		}
 
-		if err := ph.PUsecase.AddToPlaylist(uint64(pID), request.TrackID); err != nil {
-			ph.Logger.Log(c, "error", "Error while adding track to playlist.", err)
+		if err := ph.PUsecase.AddToPlaylist(uint64(pID), request.TrackID, request.Position, usr); err != nil {
+			ph.Logger.Log(c, "info", "Error while adding track to playlist.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+// MoveTracks either moves a single entry between positions
+// or rearranges the whole playlist by the list of current positions
+func (ph *PlaylistHandler) MoveTracks() echo.HandlerFunc {
+	type Request struct {
+		From  *uint64  `json:"from"`
+		To    *uint64  `json:"to"`
+		Order []uint64 `json:"order"`
+	}
+
+	correctData := func(req interface{}) bool {
+		r := req.(*Request)
+
+		if r.Order != nil {
+			return r.From == nil && r.To == nil
+		}
+
+		return r.From != nil && r.To != nil
+	}
+
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		pID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Atoi error.", err.Error())
 			return c.JSON(http.StatusInternalServerError, Response{
-				Error: ErrAlreadyExist.Error(),
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := ph.ReqReader.Read(c, request, correctData); err != nil {
+			ph.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if request.Order != nil {
+			err = ph.PUsecase.ReorderTracks(uint64(pID), request.Order, usr)
+		} else {
+			err = ph.PUsecase.MoveTrack(uint64(pID), *request.From, *request.To, usr)
+		}
+
+		if err != nil {
+			ph.Logger.Log(c, "info", "Error while moving tracks.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
 			})
 		}
 
@@ -191,6 +514,15 @@ func (ph *PlaylistHandler) AddToPlaylist() echo.HandlerFunc {
 
 func (ph *PlaylistHandler) RemoveFromPlaylist() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
 		pID, err1 := strconv.Atoi(c.Param("playlist_id"))
 		tID, err2 := strconv.Atoi(c.Param("track_id"))
 
@@ -201,9 +533,24 @@ func (ph *PlaylistHandler) RemoveFromPlaylist() echo.HandlerFunc {
 			})
 		}
 
-		if err := ph.PUsecase.RemoveFromPlaylist(uint64(pID), uint64(tID)); err != nil {
+		var position *uint64
+
+		if param := c.QueryParam("position"); param != "" {
+			pos, err := strconv.ParseUint(param, 10, 64)
+
+			if err != nil {
+				ph.Logger.Log(c, "info", "Invalid position.", err.Error())
+				return c.JSON(http.StatusBadRequest, Response{
+					Error: ErrBadParam.Error(),
+				})
+			}
+
+			position = &pos
+		}
+
+		if err := ph.PUsecase.RemoveFromPlaylist(uint64(pID), uint64(tID), position, usr); err != nil {
 			ph.Logger.Log(c, "info", "Error while remove playlist.", err)
-			return c.JSON(http.StatusBadRequest, Response{
+			return c.JSON(errorStatus(err), Response{
 				Error: err.Error(),
 			})
 		}
@@ -216,6 +563,8 @@ func (ph *PlaylistHandler) RemoveFromPlaylist() echo.HandlerFunc {
 
 func (ph *PlaylistHandler) GetSinglePlaylist() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		usr, _ := c.Get("user").(*models.User)
+
 		pID, err := strconv.Atoi(c.Param("id"))
 
 		if err != nil {
@@ -225,18 +574,18 @@ func (ph *PlaylistHandler) GetSinglePlaylist() echo.HandlerFunc {
 			})
 		}
 
-		p, amountOfTracks, err := ph.PUsecase.GetSinglePlaylist(uint64(pID))
+		p, amountOfTracks, err := ph.PUsecase.GetSinglePlaylist(uint64(pID), usr)
 
 		if err != nil {
 			ph.Logger.Log(c, "info", "Error while getting playlist.", err.Error())
-			return c.JSON(http.StatusInternalServerError, Response{
+			return c.JSON(errorStatus(err), Response{
 				Error: err.Error(),
 			})
 		}
 
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
-				"playlist": p,
+				"playlist":         p,
 				"amount_of_tracks": amountOfTracks,
 			},
 		})
@@ -245,6 +594,8 @@ func (ph *PlaylistHandler) GetSinglePlaylist() echo.HandlerFunc {
 
 func (ph *PlaylistHandler) GetTracksFromPlaylist() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		usr, _ := c.Get("user").(*models.User)
+
 		pID, err := strconv.Atoi(c.Param("id"))
 
 		if err != nil {
@@ -254,23 +605,65 @@ func (ph *PlaylistHandler) GetTracksFromPlaylist() echo.HandlerFunc {
 			})
 		}
 
-		// TODO: Strictly
+		tracks, err := ph.PUsecase.GetTracksFrom(uint64(pID), usr)
+
+		if err != nil {
+			ph.Logger.Log(c, "info", "Error while fetching tracks.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		for _, item := range tracks {
+			item.Duration = time_parser.GetDuration(item.Duration)
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"tracks": tracks,
+			},
+		})
+	}
+}
+
+func (ph *PlaylistHandler) GetSharedPlaylist() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, _ := c.Get("user").(*models.User)
+
+		p, amountOfTracks, err := ph.PUsecase.GetByLink(c.Param("link"), usr)
 
-		var authID uint64
-		if sess, ok := c.Get("session").(*models.Session); ok {
-			authID = sess.UserID
+		if err != nil {
+			ph.Logger.Log(c, "info", "Error while getting shared playlist.", err.Error())
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
 		}
 
-		tracks, err := ph.PUsecase.GetTracksFrom(uint64(pID), authID)
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"playlist":         p,
+				"amount_of_tracks": amountOfTracks,
+			},
+		})
+	}
+}
+
+func (ph *PlaylistHandler) GetTracksFromSharedPlaylist() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, _ := c.Get("user").(*models.User)
+
+		tracks, err := ph.PUsecase.GetTracksByLink(c.Param("link"), usr)
 
 		if err != nil {
-			ph.Logger.Log(c, "error", "Error while fetching tracks.", err)
-			return c.JSON(http.StatusInternalServerError, Response{
-				Error: ErrInternalServerError.Error(),
+			ph.Logger.Log(c, "info", "Error while fetching tracks.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
 			})
 		}
 
-		for _, item := range tracks { item.Duration = time_parser.GetDuration(item.Duration) }
+		for _, item := range tracks {
+			item.Duration = time_parser.GetDuration(item.Duration)
+		}
 
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
@@ -279,3 +672,524 @@ func (ph *PlaylistHandler) GetTracksFromPlaylist() echo.HandlerFunc {
 		})
 	}
 }
+
+func (ph *PlaylistHandler) GetInvitations() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		playlists, err := ph.PUsecase.FetchInvitations(usr.ID)
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Error while fetching invitations.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"playlists": playlists,
+			},
+		})
+	}
+}
+
+func (ph *PlaylistHandler) LikePlaylist() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		pID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Atoi error.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := ph.PUsecase.Like(uint64(pID), usr); err != nil {
+			ph.Logger.Log(c, "info", "Error while liking playlist.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (ph *PlaylistHandler) UnlikePlaylist() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		pID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Atoi error.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := ph.PUsecase.Unlike(uint64(pID), usr); err != nil {
+			ph.Logger.Log(c, "info", "Error while unliking playlist.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (ph *PlaylistHandler) GetCollaborators() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, _ := c.Get("user").(*models.User)
+
+		pID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Atoi error.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		collaborators, err := ph.PUsecase.GetCollaborators(uint64(pID), usr)
+
+		if err != nil {
+			ph.Logger.Log(c, "info", "Error while fetching collaborators.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"collaborators": collaborators,
+			},
+		})
+	}
+}
+
+func (ph *PlaylistHandler) InviteCollaborator() echo.HandlerFunc {
+	type Request struct {
+		UserID uint64 `json:"user_id" validate:"required"`
+	}
+
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		pID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Atoi error.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := ph.ReqReader.Read(c, request, nil); err != nil {
+			ph.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if err := ph.PUsecase.InviteCollaborator(uint64(pID), request.UserID, usr); err != nil {
+			ph.Logger.Log(c, "info", "Error while inviting collaborator.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (ph *PlaylistHandler) AcceptCollaboration() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		pID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Atoi error.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := ph.PUsecase.AcceptCollaboration(uint64(pID), usr); err != nil {
+			ph.Logger.Log(c, "info", "Error while accepting collaboration.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (ph *PlaylistHandler) RemoveCollaborator() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		pID, err1 := strconv.Atoi(c.Param("playlist_id"))
+		uID, err2 := strconv.Atoi(c.Param("user_id"))
+
+		if err1 != nil || err2 != nil {
+			ph.Logger.Log(c, "error", "Atoi error.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := ph.PUsecase.RemoveCollaborator(uint64(pID), uint64(uID), usr); err != nil {
+			ph.Logger.Log(c, "info", "Error while removing collaborator.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+// errorStatus maps usecase errors to HTTP status codes
+func errorStatus(err error) int {
+	switch err {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrPermissionDenied:
+		return http.StatusForbidden
+	case ErrAlreadyExist:
+		return http.StatusConflict
+	case ErrBadParam:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (ph *PlaylistHandler) ExportPlaylist() echo.HandlerFunc {
+	type Request struct {
+		Format string `query:"format" validate:"omitempty,oneof=m3u8 xspf"`
+	}
+
+	contentTypes := map[string]string{
+		playlist_format.M3U8: "application/vnd.apple.mpegurl",
+		playlist_format.XSPF: "application/xspf+xml",
+	}
+
+	return func(c echo.Context) error {
+		usr, _ := c.Get("user").(*models.User)
+		request := &Request{}
+
+		if err := ph.ReqReader.Read(c, request, nil); err != nil {
+			ph.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if request.Format == "" {
+			request.Format = playlist_format.M3U8
+		}
+
+		pID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			ph.Logger.Log(c, "error", "Atoi error.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		p, _, err := ph.PUsecase.GetSinglePlaylist(uint64(pID), usr)
+
+		if err != nil {
+			ph.Logger.Log(c, "info", "Error while getting playlist.", err.Error())
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		tracks, err := ph.PUsecase.GetTracksFrom(p.ID, usr)
+
+		if err != nil {
+			ph.Logger.Log(c, "info", "Error while fetching tracks.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		baseURL := c.Scheme() + "://" + c.Request().Host
+		var entries []playlist_format.Entry
+
+		for _, t := range tracks {
+			entries = append(entries, playlist_format.Entry{
+				Artist:   t.Artist,
+				Title:    t.Name,
+				Duration: playlist_format.ParseDuration(time_parser.GetDuration(t.Duration)),
+				Location: fmt.Sprintf("%s/api/v1/tracks/%d/stream", baseURL, t.ID),
+			})
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, contentTypes[request.Format]+"; charset=utf-8")
+		c.Response().Header().Set(echo.HeaderContentDisposition,
+			fmt.Sprintf("attachment; filename=\"playlist-%d.%s\"", p.ID, request.Format))
+		c.Response().WriteHeader(http.StatusOK)
+
+		if err := playlist_format.Write(request.Format, c.Response(), p.Name, entries); err != nil {
+			ph.Logger.Log(c, "error", "Error while writing playlist.", err)
+		}
+
+		return nil
+	}
+}
+
+func (ph *PlaylistHandler) ImportPlaylist() echo.HandlerFunc {
+	type Unmatched struct {
+		Line  int    `json:"line"`
+		Entry string `json:"entry"`
+	}
+
+	// Every entry is looked up in the catalogue, so both the file and
+	// the number of entries are limited
+	const maxSize = 1 << 20
+	const maxEntries = 1000
+
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			ph.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			ph.Logger.Log(c, "info", "Can't extract file from request.", err)
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrRetrievingError.Error(),
+			})
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			ph.Logger.Log(c, "error", "Can't open file.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		defer src.Close()
+
+		data, err := ioutil.ReadAll(io.LimitReader(src, maxSize+1))
+		if err != nil {
+			ph.Logger.Log(c, "error", "Can't read file.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if len(data) > maxSize {
+			ph.Logger.Log(c, "info", "Playlist file is too large.", file.Size)
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		entries, err := playlist_format.Parse(playlist_format.Detect(file.Filename, data), strings.NewReader(string(data)))
+		if err != nil {
+			ph.Logger.Log(c, "info", "Can't parse playlist.", err)
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		if len(entries) > maxEntries {
+			ph.Logger.Log(c, "info", "Too many playlist entries.", len(entries))
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		name := strings.TrimSpace(c.FormValue("name"))
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(file.Filename), filepath.Ext(file.Filename))
+		}
+
+		matched := 0
+		added := map[uint64]bool{}
+		unmatched := []Unmatched{}
+		var trackIDs []uint64
+
+		// Entries with the same title share a single catalogue lookup
+		candidates := map[string][]*models.Track{}
+
+		for _, entry := range entries {
+			t, err := ph.matchTrack(entry, candidates, usr.ID)
+
+			if err != nil {
+				ph.Logger.Log(c, "error", "Error while matching track.", err)
+				return c.JSON(http.StatusInternalServerError, Response{
+					Error: ErrInternalServerError.Error(),
+				})
+			}
+
+			if t == nil {
+				unmatched = append(unmatched, Unmatched{Line: entry.Line, Entry: entry.Raw})
+				continue
+			}
+
+			matched++
+
+			if added[t.ID] {
+				continue
+			}
+
+			trackIDs = append(trackIDs, t.ID)
+			added[t.ID] = true
+		}
+
+		p := models.NewPlaylist(name, c.FormValue("description"), usr.ID)
+
+		if err := ph.PUsecase.Import(p, trackIDs); err != nil {
+			ph.Logger.Log(c, "info", "Error while importing playlist.", err)
+			return c.JSON(errorStatus(err), Response{
+				Error: err.Error(),
+				Body: &Body{
+					"unmatched": unmatched,
+				},
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"playlist":  p,
+				"matched":   matched,
+				"unmatched": unmatched,
+			},
+		})
+	}
+}
+
+// matchTrack looks the entry up in the catalogue by title and picks the
+// candidate whose artist and duration fit best. Durations within
+// a few seconds are treated as equal. Candidates found are cached by title.
+func (ph *PlaylistHandler) matchTrack(entry playlist_format.Entry, cache map[string][]*models.Track,
+	authID uint64) (*models.Track, error) {
+	const candidates = 20
+	const tolerance = 3
+
+	if entry.Title == "" {
+		return nil, nil
+	}
+
+	key := strings.ToLower(entry.Title)
+	tracks, ok := cache[key]
+
+	if !ok {
+		var err error
+		tracks, err = ph.TUsecase.FindLike(entry.Title, candidates, authID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cache[key] = tracks
+	}
+
+	var best *models.Track
+	bestScore := 0
+
+	for _, t := range tracks {
+		if !strings.EqualFold(strings.TrimSpace(t.Name), entry.Title) {
+			continue
+		}
+
+		score := 1
+
+		if entry.Artist != "" {
+			if !strings.EqualFold(strings.TrimSpace(t.Artist), entry.Artist) {
+				continue
+			}
+
+			score += 2
+		}
+
+		if entry.Duration != 0 {
+			diff := playlist_format.ParseDuration(t.Duration) - entry.Duration
+
+			if diff > tolerance || diff < -tolerance {
+				continue
+			}
+
+			score++
+		}
+
+		if score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+
+	return best, nil
+}
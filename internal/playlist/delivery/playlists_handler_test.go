@@ -0,0 +1,275 @@
+package delivery
+
+import (
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/playlist"
+	"2019_2_Covenant/pkg/logger"
+	. "2019_2_Covenant/tools/vars"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// stubUsecase answers every call with the same error
+type stubUsecase struct {
+	playlist.Usecase
+	err     error
+	current *models.Playlist
+	updated *models.Playlist
+}
+
+func (s *stubUsecase) DeleteByID(playlistID uint64, usr *models.User) error {
+	return s.err
+}
+
+func (s *stubUsecase) Update(p *models.Playlist, usr *models.User) error {
+	s.updated = p
+	return s.err
+}
+
+func (s *stubUsecase) AddToPlaylist(playlistID uint64, trackID uint64, position *uint64, usr *models.User) error {
+	return s.err
+}
+
+func (s *stubUsecase) MoveTrack(playlistID uint64, from uint64, to uint64, usr *models.User) error {
+	return s.err
+}
+
+func (s *stubUsecase) RemoveFromPlaylist(playlistID uint64, trackID uint64, position *uint64, usr *models.User) error {
+	return s.err
+}
+
+func (s *stubUsecase) GetSinglePlaylist(playlistID uint64, usr *models.User) (*models.Playlist, uint64, error) {
+	if s.err != nil {
+		return nil, 0, s.err
+	}
+
+	if s.current != nil {
+		copied := *s.current
+		return &copied, 0, nil
+	}
+
+	return &models.Playlist{ID: playlistID}, 0, nil
+}
+
+func (s *stubUsecase) GetTracksFrom(playlistID uint64, usr *models.User) ([]*models.Track, error) {
+	return nil, s.err
+}
+
+func (s *stubUsecase) CanManage(playlistID uint64, usr *models.User) error {
+	return s.err
+}
+
+func (s *stubUsecase) ResetPhoto(playlistID uint64, usr *models.User) error {
+	return s.err
+}
+
+func (s *stubUsecase) Like(playlistID uint64, usr *models.User) error {
+	return s.err
+}
+
+func (s *stubUsecase) Unlike(playlistID uint64, usr *models.User) error {
+	return s.err
+}
+
+type route struct {
+	method  string
+	handler func(ph *PlaylistHandler) echo.HandlerFunc
+	params  []string
+	body    string
+}
+
+var routes = map[string]route{
+	"delete": {
+		method:  http.MethodDelete,
+		handler: (*PlaylistHandler).DeletePlaylist,
+		params:  []string{"id"},
+	},
+	"update": {
+		method:  http.MethodPut,
+		handler: (*PlaylistHandler).UpdatePlaylist,
+		params:  []string{"id"},
+		body:    `{"name":"renamed","visibility":0}`,
+	},
+	"reset photo": {
+		method:  http.MethodDelete,
+		handler: (*PlaylistHandler).ResetPlaylistPhoto,
+		params:  []string{"id"},
+	},
+	"add": {
+		method:  http.MethodPost,
+		handler: (*PlaylistHandler).AddToPlaylist,
+		params:  []string{"id"},
+		body:    `{"track_id":1}`,
+	},
+	"move": {
+		method:  http.MethodPut,
+		handler: (*PlaylistHandler).MoveTracks,
+		params:  []string{"id"},
+		body:    `{"from":0,"to":1}`,
+	},
+	"remove": {
+		method:  http.MethodDelete,
+		handler: (*PlaylistHandler).RemoveFromPlaylist,
+		params:  []string{"playlist_id", "track_id"},
+	},
+	"get": {
+		method:  http.MethodGet,
+		handler: (*PlaylistHandler).GetSinglePlaylist,
+		params:  []string{"id"},
+	},
+	"tracks": {
+		method:  http.MethodGet,
+		handler: (*PlaylistHandler).GetTracksFromPlaylist,
+		params:  []string{"id"},
+	},
+	"like": {
+		method:  http.MethodPost,
+		handler: (*PlaylistHandler).LikePlaylist,
+		params:  []string{"id"},
+	},
+	"unlike": {
+		method:  http.MethodDelete,
+		handler: (*PlaylistHandler).UnlikePlaylist,
+		params:  []string{"id"},
+	},
+}
+
+func serve(r route, err error) *httptest.ResponseRecorder {
+	log := logger.NewLogrusLogger()
+	log.L.Out = ioutil.Discard
+	ph := NewPlaylistHandler(&stubUsecase{err: err}, nil, nil, log)
+
+	e := echo.New()
+	req := httptest.NewRequest(r.method, "/", strings.NewReader(r.body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetParamNames(r.params...)
+
+	values := make([]string, len(r.params))
+	for i := range values {
+		values[i] = "1"
+	}
+
+	c.SetParamValues(values...)
+	c.Set("user", &models.User{ID: 1})
+
+	if err := r.handler(ph)(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+
+	return rec
+}
+
+func TestPlaylistHandler_Status(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"owner", nil, http.StatusOK},
+		{"non-owner", ErrPermissionDenied, http.StatusForbidden},
+		{"missing", ErrNotFound, http.StatusNotFound},
+		{"conflict", ErrAlreadyExist, http.StatusConflict},
+		{"bad param", ErrBadParam, http.StatusBadRequest},
+	}
+
+	for name, r := range routes {
+		for _, c := range cases {
+			if rec := serve(r, c.err); rec.Code != c.want {
+				t.Errorf("%s, %s: got %d, want %d", name, c.name, rec.Code, c.want)
+			}
+		}
+	}
+}
+
+func TestPlaylistHandler_UploadPhotoAccess(t *testing.T) {
+	upload := route{
+		method:  http.MethodPost,
+		handler: (*PlaylistHandler).UploadPlaylistPhoto,
+		params:  []string{"id"},
+	}
+
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"non-owner", ErrPermissionDenied, http.StatusForbidden},
+		{"missing", ErrNotFound, http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		if rec := serve(upload, c.err); rec.Code != c.want {
+			t.Errorf("%s: got %d, want %d", c.name, rec.Code, c.want)
+		}
+	}
+}
+
+func TestPlaylistHandler_BadPosition(t *testing.T) {
+	log := logger.NewLogrusLogger()
+	log.L.Out = ioutil.Discard
+	ph := NewPlaylistHandler(&stubUsecase{}, nil, nil, log)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodDelete, "/?position=first", nil)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetParamNames("playlist_id", "track_id")
+	c.SetParamValues("1", "1")
+	c.Set("user", &models.User{ID: 1})
+
+	if err := ph.RemoveFromPlaylist()(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("got %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestPlaylistHandler_PartialUpdate(t *testing.T) {
+	log := logger.NewLogrusLogger()
+	log.L.Out = ioutil.Discard
+	uc := &stubUsecase{current: &models.Playlist{
+		ID:              1,
+		Name:            "old",
+		Description:     "kept",
+		AllowDuplicates: true,
+		Visibility:      PLAYLIST_PUBLIC,
+	}}
+	ph := NewPlaylistHandler(uc, nil, nil, log)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"name":"renamed"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	c.Set("user", &models.User{ID: 1})
+
+	if err := ph.UpdatePlaylist()(c); err != nil {
+		t.Fatal(err)
+	}
+
+	want := models.Playlist{
+		ID:              1,
+		Name:            "renamed",
+		Description:     "kept",
+		AllowDuplicates: true,
+		Visibility:      PLAYLIST_PUBLIC,
+	}
+
+	if rec.Code != http.StatusOK || uc.updated == nil || *uc.updated != want {
+		t.Errorf("got %d, %+v, want %+v", rec.Code, uc.updated, want)
+	}
+}
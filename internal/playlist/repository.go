@@ -4,10 +4,27 @@ import "2019_2_Covenant/internal/models"
 
 type Repository interface {
 	Store(playlist *models.Playlist) error
-	Fetch(userID uint64, count uint64, offset uint64) ([]*models.Playlist, uint64, error)
+	Import(playlist *models.Playlist, trackIDs []uint64) error
+	Fetch(userID uint64, viewerID uint64, count uint64, offset uint64) ([]*models.Playlist, uint64, error)
+	FetchInvitations(userID uint64) ([]*models.Playlist, error)
 	DeleteByID(playlistID uint64) error
-	AddToPlaylist(playlistID uint64, trackID uint64) error
-	RemoveFromPlaylist(playlistID uint64, trackID uint64) error
+	Update(playlist *models.Playlist) error
+	AddToPlaylist(playlistID uint64, trackID uint64, position *uint64) error
+	MoveTrack(playlistID uint64, from uint64, to uint64) error
+	ReorderTracks(playlistID uint64, order []uint64) error
+	RemoveFromPlaylist(playlistID uint64, trackID uint64, position *uint64) error
 	GetSinglePlaylist(playlistID uint64) (*models.Playlist, uint64, error)
+	GetByLink(link string) (*models.Playlist, uint64, error)
 	GetTracksFrom(playlistID uint64, authID uint64) ([]*models.Track, error)
+	UpdatePhoto(playlistID uint64, path string, custom bool) error
+	GetCovers(playlistID uint64, count uint64) ([]string, error)
+	Like(playlistID uint64, userID uint64) error
+	Unlike(playlistID uint64, userID uint64) error
+	AddCollaborator(playlistID uint64, userID uint64) error
+	AcceptCollaboration(playlistID uint64, userID uint64) error
+	RemoveCollaborator(playlistID uint64, userID uint64) error
+	GetCollaboration(playlistID uint64, userID uint64) (bool, error)
+	GetCollaborators(playlistID uint64) ([]*models.Collaborator, error)
+	FindLike(variants []string, viewerID uint64, count uint64, offset uint64) ([]*models.Playlist, error)
+	CountLike(variants []string, viewerID uint64) (uint64, error)
 }
@@ -3,8 +3,10 @@ package repository
 import (
 	"2019_2_Covenant/internal/models"
 	"2019_2_Covenant/internal/playlist"
+	. "2019_2_Covenant/tools/search_query"
 	. "2019_2_Covenant/tools/vars"
 	"database/sql"
+	"fmt"
 )
 
 type PlaylistRepository struct {
@@ -18,27 +20,93 @@ func NewPlaylistRepository(db *sql.DB) playlist.Repository {
 }
 
 func (plR *PlaylistRepository) Store(playlist *models.Playlist) error {
-	return plR.db.QueryRow("INSERT INTO playlists (name, description, owner_id) VALUES ($1, $2, $3) RETURNING id, photo",
+	return plR.db.QueryRow("INSERT INTO playlists (name, description, owner_id, allow_duplicates, visibility) "+
+		"VALUES ($1, $2, $3, $4, $5) RETURNING id, photo, link",
 		playlist.Name,
 		playlist.Description,
 		playlist.OwnerID,
-	).Scan(&playlist.ID, &playlist.Photo)
+		playlist.AllowDuplicates,
+		playlist.Visibility,
+	).Scan(&playlist.ID, &playlist.Photo, &playlist.Link)
 }
 
-func (plR *PlaylistRepository) Fetch(userID uint64, count uint64, offset uint64) ([]*models.Playlist, uint64, error) {
+// Import stores the playlist together with its tracks in the given order
+func (plR *PlaylistRepository) Import(playlist *models.Playlist, trackIDs []uint64) error {
+	tx, err := plR.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if err := tx.QueryRow("INSERT INTO playlists (name, description, owner_id, allow_duplicates, visibility) "+
+		"VALUES ($1, $2, $3, $4, $5) RETURNING id, photo, link",
+		playlist.Name,
+		playlist.Description,
+		playlist.OwnerID,
+		playlist.AllowDuplicates,
+		playlist.Visibility,
+	).Scan(&playlist.ID, &playlist.Photo, &playlist.Link); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for position, trackID := range trackIDs {
+		if _, err := tx.Exec("INSERT INTO playlist_track (playlist_id, track_id, position) VALUES ($1, $2, $3)",
+			playlist.ID,
+			trackID,
+			position,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (plR *PlaylistRepository) Update(playlist *models.Playlist) error {
+	if err := plR.db.QueryRow("UPDATE playlists SET name = $1, description = $2, allow_duplicates = $3, "+
+		"visibility = $4, updated_at = now() WHERE id = $5 RETURNING owner_id, photo, link",
+		playlist.Name,
+		playlist.Description,
+		playlist.AllowDuplicates,
+		playlist.Visibility,
+		playlist.ID,
+	).Scan(&playlist.OwnerID, &playlist.Photo, &playlist.Link); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Fetch returns user's playlists visible to the viewer: all of them for the owner,
+// otherwise public ones and the ones the viewer collaborates on; pending invitations don't count
+func (plR *PlaylistRepository) Fetch(userID uint64, viewerID uint64, count uint64, offset uint64) ([]*models.Playlist, uint64, error) {
 	var playlists []*models.Playlist
 	var total uint64
 
-	if err := plR.db.QueryRow("SELECT COUNT(*) FROM playlists WHERE owner_id = $1",
+	visible := "owner_id = $1 AND ($1 = $2 OR visibility = $3 OR " +
+		"id IN (SELECT playlist_id FROM playlist_collaborators WHERE user_id = $2 AND accepted))"
+
+	if err := plR.db.QueryRow("SELECT COUNT(*) FROM playlists WHERE "+visible,
 		userID,
+		viewerID,
+		PLAYLIST_PUBLIC,
 	).Scan(
 		&total,
 	); err != nil {
 		return nil, total, err
 	}
 
-	rows, err := plR.db.Query("SELECT id, name, description, photo FROM playlists WHERE owner_id = $1 LIMIT $2 OFFSET $3",
+	rows, err := plR.db.Query("SELECT id, name, description, photo, allow_duplicates, visibility, link "+
+		"FROM playlists WHERE "+visible+" ORDER BY id LIMIT $4 OFFSET $5",
 		userID,
+		viewerID,
+		PLAYLIST_PUBLIC,
 		count,
 		offset,
 	)
@@ -57,6 +125,9 @@ func (plR *PlaylistRepository) Fetch(userID uint64, count uint64, offset uint64)
 			&p.Name,
 			&p.Description,
 			&p.Photo,
+			&p.AllowDuplicates,
+			&p.Visibility,
+			&p.Link,
 		); err != nil {
 			return nil, total, err
 		}
@@ -73,6 +144,46 @@ func (plR *PlaylistRepository) Fetch(userID uint64, count uint64, offset uint64)
 	return playlists, total, nil
 }
 
+func (plR *PlaylistRepository) FetchInvitations(userID uint64) ([]*models.Playlist, error) {
+	var playlists []*models.Playlist
+
+	rows, err := plR.db.Query("SELECT P.id, P.owner_id, P.name, P.description, P.photo, P.allow_duplicates, P.visibility "+
+		"FROM playlists P JOIN playlist_collaborators C ON P.id = C.playlist_id "+
+		"WHERE C.user_id = $1 AND NOT C.accepted ORDER BY C.created_at DESC",
+		userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		p := &models.Playlist{}
+
+		if err := rows.Scan(
+			&p.ID,
+			&p.OwnerID,
+			&p.Name,
+			&p.Description,
+			&p.Photo,
+			&p.AllowDuplicates,
+			&p.Visibility,
+		); err != nil {
+			return nil, err
+		}
+
+		playlists = append(playlists, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return playlists, nil
+}
+
 func (plR *PlaylistRepository) DeleteByID(playlistID uint64) error {
 	if err := plR.db.QueryRow("DELETE FROM playlists WHERE id = $1 RETURNING id",
 		playlistID,
@@ -87,53 +198,309 @@ func (plR *PlaylistRepository) DeleteByID(playlistID uint64) error {
 	return nil
 }
 
-func (plR *PlaylistRepository) AddToPlaylist(playlistID uint64, trackID uint64) error {
-	if err := plR.db.QueryRow("SELECT id FROM playlist_track WHERE playlist_id = $1 AND track_id = $2",
+// AddToPlaylist inserts the track at the position shifting the following tracks,
+// nil position or position past the end appends the track
+func (plR *PlaylistRepository) AddToPlaylist(playlistID uint64, trackID uint64, position *uint64) error {
+	tx, err := plR.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	var allowDuplicates bool
+
+	if err := tx.QueryRow("SELECT allow_duplicates FROM playlists WHERE id = $1 FOR UPDATE",
 		playlistID,
+	).Scan(&allowDuplicates); err != nil {
+		tx.Rollback()
+
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	var id uint64
+
+	if err := tx.QueryRow("SELECT id FROM tracks WHERE id = $1",
 		trackID,
-	).Scan(); err == nil {
-		return ErrAlreadyExist
+	).Scan(&id); err != nil {
+		tx.Rollback()
+
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	if !allowDuplicates {
+		if err := tx.QueryRow("SELECT id FROM playlist_track WHERE playlist_id = $1 AND track_id = $2 LIMIT 1",
+			playlistID,
+			trackID,
+		).Scan(&id); err == nil {
+			tx.Rollback()
+			return ErrAlreadyExist
+		}
+	}
+
+	var amount uint64
+
+	if err := tx.QueryRow("SELECT COUNT(*) FROM playlist_track WHERE playlist_id = $1",
+		playlistID,
+	).Scan(&amount); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	pos := amount
+	if position != nil && *position < amount {
+		pos = *position
+	}
+
+	if _, err := tx.Exec("UPDATE playlist_track SET position = position + 1 WHERE playlist_id = $1 AND position >= $2",
+		playlistID,
+		pos,
+	); err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	if _, err := plR.db.Exec("INSERT INTO playlist_track (playlist_id, track_id) VALUES ($1, $2)",
+	if _, err := tx.Exec("INSERT INTO playlist_track (playlist_id, track_id, position) VALUES ($1, $2, $3)",
 		playlistID,
 		trackID,
+		pos,
 	); err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-func (plR *PlaylistRepository) RemoveFromPlaylist(playlistID uint64, trackID uint64) error {
-	res, err := plR.db.Exec("DELETE from playlist_track WHERE playlist_id = $1 AND track_id = $2",
+// RemoveFromPlaylist removes a single entry of the track and closes the gap in positions:
+// the one at the position if given, otherwise the first one
+func (plR *PlaylistRepository) RemoveFromPlaylist(playlistID uint64, trackID uint64, position *uint64) error {
+	tx, err := plR.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if err := lockPlaylist(tx, playlistID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM playlist_track WHERE id = ("+
+		"SELECT id FROM playlist_track WHERE playlist_id = $1 AND track_id = $2 "+
+		"AND ($3::bigint IS NULL OR position = $3) ORDER BY position LIMIT 1 FOR UPDATE)",
 		playlistID,
 		trackID,
+		position,
 	)
 
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
 	if rows, _ := res.RowsAffected(); rows == 0 {
+		tx.Rollback()
 		return ErrNotFound
 	}
 
+	if err := renumber(tx, playlistID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (plR *PlaylistRepository) MoveTrack(playlistID uint64, from uint64, to uint64) error {
+	tx, err := plR.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if err := lockPlaylist(tx, playlistID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var entryID, amount uint64
+
+	if err := tx.QueryRow("SELECT COUNT(*) FROM playlist_track WHERE playlist_id = $1",
+		playlistID,
+	).Scan(&amount); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if from >= amount || to >= amount {
+		tx.Rollback()
+		return ErrBadParam
+	}
+
+	if err := tx.QueryRow("SELECT id FROM playlist_track WHERE playlist_id = $1 AND position = $2 FOR UPDATE",
+		playlistID,
+		from,
+	).Scan(&entryID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := "UPDATE playlist_track SET position = position + 1 WHERE playlist_id = $1 AND position >= $2 AND position < $3"
+	lower, upper := to, from
+
+	if from < to {
+		query = "UPDATE playlist_track SET position = position - 1 WHERE playlist_id = $1 AND position > $2 AND position <= $3"
+		lower, upper = from, to
+	}
+
+	if _, err := tx.Exec(query, playlistID, lower, upper); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE playlist_track SET position = $1 WHERE id = $2",
+		to,
+		entryID,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReorderTracks rearranges the playlist by the list of current positions
+// given in the desired order, the list must be a permutation of all positions
+func (plR *PlaylistRepository) ReorderTracks(playlistID uint64, order []uint64) error {
+	tx, err := plR.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if err := lockPlaylist(tx, playlistID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	rows, err := tx.Query("SELECT id, position FROM playlist_track WHERE playlist_id = $1 FOR UPDATE",
+		playlistID,
+	)
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	entries := make(map[uint64]uint64)
+
+	for rows.Next() {
+		var id, position uint64
+
+		if err := rows.Scan(&id, &position); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+
+		entries[position] = id
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if len(order) != len(entries) {
+		tx.Rollback()
+		return ErrBadParam
+	}
+
+	seen := make(map[uint64]bool, len(order))
+
+	for _, position := range order {
+		if _, ok := entries[position]; !ok || seen[position] {
+			tx.Rollback()
+			return ErrBadParam
+		}
+
+		seen[position] = true
+	}
+
+	for newPosition, oldPosition := range order {
+		if _, err := tx.Exec("UPDATE playlist_track SET position = $1 WHERE id = $2",
+			newPosition,
+			entries[oldPosition],
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// lockPlaylist serialises changes of the playlist's positions: all of them
+// take the lock of the playlist row before touching its entries
+func lockPlaylist(tx *sql.Tx, playlistID uint64) error {
+	if err := tx.QueryRow("SELECT id FROM playlists WHERE id = $1 FOR UPDATE",
+		playlistID,
+	).Scan(&playlistID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
 	return nil
 }
 
+func renumber(tx *sql.Tx, playlistID uint64) error {
+	_, err := tx.Exec("UPDATE playlist_track PT SET position = S.rn - 1 FROM ("+
+		"SELECT id, row_number() over (ORDER BY position) rn FROM playlist_track WHERE playlist_id = $1"+
+		") S WHERE PT.id = S.id",
+		playlistID,
+	)
+
+	return err
+}
+
 func (plR *PlaylistRepository) GetSinglePlaylist(playlistID uint64) (*models.Playlist, uint64, error) {
+	return plR.getPlaylist("id = $1", playlistID)
+}
+
+func (plR *PlaylistRepository) GetByLink(link string) (*models.Playlist, uint64, error) {
+	return plR.getPlaylist("link = $1", link)
+}
+
+func (plR *PlaylistRepository) getPlaylist(condition string, arg interface{}) (*models.Playlist, uint64, error) {
 	p := &models.Playlist{}
 	var amountOfTracks uint64
 
-	if err := plR.db.QueryRow("SELECT id, name, description, photo, owner_id FROM playlists WHERE id = $1",
-		playlistID,
+	if err := plR.db.QueryRow("SELECT id, name, description, photo, custom_photo, owner_id, allow_duplicates, visibility, link "+
+		"FROM playlists WHERE "+condition,
+		arg,
 	).Scan(
 		&p.ID,
 		&p.Name,
 		&p.Description,
 		&p.Photo,
+		&p.CustomPhoto,
 		&p.OwnerID,
+		&p.AllowDuplicates,
+		&p.Visibility,
+		&p.Link,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, amountOfTracks, ErrNotFound
@@ -143,7 +510,7 @@ func (plR *PlaylistRepository) GetSinglePlaylist(playlistID uint64) (*models.Pla
 	}
 
 	if err := plR.db.QueryRow("SELECT COUNT(*) FROM playlist_track WHERE playlist_id = $1",
-		playlistID,
+		p.ID,
 	).Scan(&amountOfTracks); err != nil {
 		if err == sql.ErrNoRows {
 			return p, amountOfTracks, nil
@@ -159,12 +526,12 @@ func (plR *PlaylistRepository) GetTracksFrom(playlistID uint64, authID uint64) (
 	var tracks []*models.Track
 
 	rows, err := plR.db.Query(
-		"select T.id, T.name, T.duration, T.path, Ar.name, Ar.id, " +
-			"T.id in (select track_id from favourites where user_id = $1) AS favourite, " +
-			"T.id in (select track_id from likes where user_id = $1) AS liked from playlist_track PT " +
-			"join tracks T ON PT.track_id=T.id join albums Al ON T.album_id=Al.id " +
-			"join artists Ar ON Al.artist_id=Ar.id where PT.playlist_id = $2;",
-			authID,
+		"select T.id, T.name, T.duration, T.path, Ar.name, Ar.id, PT.position, "+
+			"T.id in (select track_id from favourites where user_id = $1) AS favourite, "+
+			"T.id in (select track_id from likes where user_id = $1) AS liked from playlist_track PT "+
+			"join tracks T ON PT.track_id=T.id join albums Al ON T.album_id=Al.id "+
+			"join artists Ar ON Al.artist_id=Ar.id where PT.playlist_id = $2 ORDER BY PT.position;",
+		authID,
 		playlistID)
 
 	if err != nil {
@@ -174,11 +541,11 @@ func (plR *PlaylistRepository) GetTracksFrom(playlistID uint64, authID uint64) (
 	defer rows.Close()
 
 	for rows.Next() {
-		t := &models.Track{}
+		t := &models.Track{Position: new(uint64)}
 		isFavourite := new(bool)
 		isLiked := new(bool)
 
-		if err := rows.Scan(&t.ID, &t.Name, &t.Duration, &t.Path, &t.Artist, &t.ArtistID, isFavourite, isLiked); err != nil {
+		if err := rows.Scan(&t.ID, &t.Name, &t.Duration, &t.Path, &t.Artist, &t.ArtistID, t.Position, isFavourite, isLiked); err != nil {
 			return nil, err
 		}
 
@@ -196,3 +563,280 @@ func (plR *PlaylistRepository) GetTracksFrom(playlistID uint64, authID uint64) (
 
 	return tracks, nil
 }
+
+func (plR *PlaylistRepository) Like(playlistID uint64, userID uint64) error {
+	var id uint64
+
+	if err := plR.db.QueryRow("SELECT id FROM playlist_likes WHERE playlist_id = $1 AND user_id = $2",
+		playlistID,
+		userID,
+	).Scan(&id); err == nil {
+		return ErrAlreadyExist
+	}
+
+	if _, err := plR.db.Exec("INSERT INTO playlist_likes (playlist_id, user_id) VALUES ($1, $2)",
+		playlistID,
+		userID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (plR *PlaylistRepository) Unlike(playlistID uint64, userID uint64) error {
+	res, err := plR.db.Exec("DELETE FROM playlist_likes WHERE playlist_id = $1 AND user_id = $2",
+		playlistID,
+		userID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (plR *PlaylistRepository) AddCollaborator(playlistID uint64, userID uint64) error {
+	var id uint64
+
+	if err := plR.db.QueryRow("SELECT id FROM playlist_collaborators WHERE playlist_id = $1 AND user_id = $2",
+		playlistID,
+		userID,
+	).Scan(&id); err == nil {
+		return ErrAlreadyExist
+	}
+
+	if _, err := plR.db.Exec("INSERT INTO playlist_collaborators (playlist_id, user_id) VALUES ($1, $2)",
+		playlistID,
+		userID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (plR *PlaylistRepository) AcceptCollaboration(playlistID uint64, userID uint64) error {
+	res, err := plR.db.Exec("UPDATE playlist_collaborators SET accepted = true WHERE playlist_id = $1 AND user_id = $2",
+		playlistID,
+		userID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (plR *PlaylistRepository) RemoveCollaborator(playlistID uint64, userID uint64) error {
+	res, err := plR.db.Exec("DELETE FROM playlist_collaborators WHERE playlist_id = $1 AND user_id = $2",
+		playlistID,
+		userID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetCollaboration reports whether the user accepted the invitation to the playlist,
+// ErrNotFound means the user is not invited at all
+func (plR *PlaylistRepository) GetCollaboration(playlistID uint64, userID uint64) (bool, error) {
+	var accepted bool
+
+	if err := plR.db.QueryRow("SELECT accepted FROM playlist_collaborators WHERE playlist_id = $1 AND user_id = $2",
+		playlistID,
+		userID,
+	).Scan(&accepted); err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrNotFound
+		}
+
+		return false, err
+	}
+
+	return accepted, nil
+}
+
+func (plR *PlaylistRepository) GetCollaborators(playlistID uint64) ([]*models.Collaborator, error) {
+	var collaborators []*models.Collaborator
+
+	rows, err := plR.db.Query("SELECT U.id, U.nickname, U.avatar, C.accepted FROM playlist_collaborators C "+
+		"JOIN users U ON C.user_id = U.id WHERE C.playlist_id = $1 ORDER BY U.nickname",
+		playlistID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		c := &models.Collaborator{}
+
+		if err := rows.Scan(&c.UserID, &c.Nickname, &c.Avatar, &c.Accepted); err != nil {
+			return nil, err
+		}
+
+		collaborators = append(collaborators, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collaborators, nil
+}
+
+func (plR *PlaylistRepository) UpdatePhoto(playlistID uint64, path string, custom bool) error {
+	if err := plR.db.QueryRow("UPDATE playlists SET photo = $1, custom_photo = $2 WHERE id = $3 RETURNING id",
+		path,
+		custom,
+		playlistID,
+	).Scan(&playlistID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// GetCovers returns distinct album covers in order of their first appearance in the playlist
+func (plR *PlaylistRepository) GetCovers(playlistID uint64, count uint64) ([]string, error) {
+	var covers []string
+
+	rows, err := plR.db.Query("SELECT S.photo FROM ("+
+		"SELECT Al.photo, MIN(PT.position) AS position FROM playlist_track PT "+
+		"JOIN tracks T ON PT.track_id = T.id JOIN albums Al ON T.album_id = Al.id "+
+		"WHERE PT.playlist_id = $1 GROUP BY Al.photo"+
+		") S ORDER BY S.position LIMIT $2",
+		playlistID,
+		count,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var cover string
+
+		if err := rows.Scan(&cover); err != nil {
+			return nil, err
+		}
+
+		covers = append(covers, cover)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return covers, nil
+}
+
+// likeCondition matches playlists by name or description among those the
+// viewer may see: own ones, ones the viewer collaborates on and public ones
+// of public profiles or of profiles the viewer follows.
+func likeCondition(args *Args, variants []string, viewerID uint64) (string, []string) {
+	params := args.AddVariants(variants)
+	viewer := args.Add(viewerID)
+
+	return fmt.Sprintf("(%s OR %s) AND (P.owner_id = %s OR "+
+		"P.id IN (SELECT playlist_id FROM playlist_collaborators WHERE user_id = %s AND accepted) OR "+
+		"P.visibility = %s AND (P.owner_id IN (SELECT id FROM users WHERE access = %s) OR "+
+		"P.owner_id IN (SELECT subscribed_to FROM subscriptions WHERE user_id = %s)))",
+		Match("P.name", params, true),
+		Match("coalesce(P.description, '')", params, true),
+		viewer,
+		viewer,
+		args.Add(PLAYLIST_PUBLIC),
+		args.Add(PROFILE_PUBLIC),
+		viewer,
+	), params
+}
+
+func (plR *PlaylistRepository) CountLike(variants []string, viewerID uint64) (uint64, error) {
+	var total uint64
+
+	args := &Args{}
+	cond, _ := likeCondition(args, variants, viewerID)
+
+	if err := plR.db.QueryRow("SELECT COUNT(*) FROM playlists P WHERE "+cond, *args...).Scan(&total); err != nil {
+		return total, err
+	}
+
+	return total, nil
+}
+
+func (plR *PlaylistRepository) FindLike(variants []string, viewerID uint64, count uint64, offset uint64) ([]*models.Playlist, error) {
+	var playlists []*models.Playlist
+
+	args := &Args{}
+	cond, params := likeCondition(args, variants, viewerID)
+
+	rows, err := plR.db.Query(fmt.Sprintf(
+		"SELECT P.id, P.owner_id, P.name, coalesce(P.description, ''), P.photo, P.allow_duplicates, P.visibility "+
+			"FROM playlists P WHERE %s "+
+			"ORDER BY 0.8 * greatest(%s, 0.7 * %s) + 0.2 * %s DESC, P.id "+
+			"LIMIT %s OFFSET %s",
+		cond,
+		Similarity("P.name", params),
+		Similarity("coalesce(P.description, '')", params),
+		TextRank("P.name", params),
+		args.Add(count),
+		args.Add(offset),
+	), *args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		p := &models.Playlist{}
+
+		if err := rows.Scan(
+			&p.ID,
+			&p.OwnerID,
+			&p.Name,
+			&p.Description,
+			&p.Photo,
+			&p.AllowDuplicates,
+			&p.Visibility,
+		); err != nil {
+			return nil, err
+		}
+
+		playlists = append(playlists, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return playlists, nil
+}
@@ -4,10 +4,26 @@ import "2019_2_Covenant/internal/models"
 
 type Usecase interface {
 	Store(playlist *models.Playlist) error
-	Fetch(userID uint64, count uint64, offset uint64) ([]*models.Playlist, uint64, error)
-	DeleteByID(playlistID uint64) error
-	AddToPlaylist(playlistID uint64, trackID uint64) error
-	RemoveFromPlaylist(playlistID uint64, trackID uint64) error
-	GetSinglePlaylist(playlistID uint64) (*models.Playlist, uint64, error)
-	GetTracksFrom(playlistID uint64, authID uint64) ([]*models.Track, error)
+	Import(playlist *models.Playlist, trackIDs []uint64) error
+	Fetch(userID uint64, viewer *models.User, count uint64, offset uint64) ([]*models.Playlist, uint64, error)
+	FetchInvitations(userID uint64) ([]*models.Playlist, error)
+	DeleteByID(playlistID uint64, usr *models.User) error
+	Update(playlist *models.Playlist, usr *models.User) error
+	AddToPlaylist(playlistID uint64, trackID uint64, position *uint64, usr *models.User) error
+	MoveTrack(playlistID uint64, from uint64, to uint64, usr *models.User) error
+	ReorderTracks(playlistID uint64, order []uint64, usr *models.User) error
+	RemoveFromPlaylist(playlistID uint64, trackID uint64, position *uint64, usr *models.User) error
+	GetSinglePlaylist(playlistID uint64, usr *models.User) (*models.Playlist, uint64, error)
+	GetByLink(link string, usr *models.User) (*models.Playlist, uint64, error)
+	GetTracksFrom(playlistID uint64, usr *models.User) ([]*models.Track, error)
+	GetTracksByLink(link string, usr *models.User) ([]*models.Track, error)
+	CanManage(playlistID uint64, usr *models.User) error
+	UpdatePhoto(playlistID uint64, path string, usr *models.User) error
+	ResetPhoto(playlistID uint64, usr *models.User) error
+	Like(playlistID uint64, usr *models.User) error
+	Unlike(playlistID uint64, usr *models.User) error
+	InviteCollaborator(playlistID uint64, userID uint64, usr *models.User) error
+	AcceptCollaboration(playlistID uint64, usr *models.User) error
+	RemoveCollaborator(playlistID uint64, userID uint64, usr *models.User) error
+	GetCollaborators(playlistID uint64, usr *models.User) ([]*models.Collaborator, error)
 }
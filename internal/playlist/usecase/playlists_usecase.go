@@ -1,18 +1,60 @@
 package usecase
 
 import (
+	"2019_2_Covenant/internal/feed"
 	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/notifications"
 	"2019_2_Covenant/internal/playlist"
+	"2019_2_Covenant/internal/realtime"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/tools/mosaic"
 	. "2019_2_Covenant/tools/vars"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Levels of access to a playlist
+const (
+	viewAccess = iota
+	editAccess
+	manageAccess
+)
+
+// Kinds of playlist changes pushed to clients
+const (
+	changeInfo   = "info"
+	changeTracks = "tracks"
+	changePhoto  = "photo"
+	changeDelete = "delete"
 )
 
 type PlaylistUsecase struct {
-	playlistRepo playlist.Repository
+	playlistRepo     playlist.Repository
+	feedRepo         feed.Repository
+	notificationRepo notifications.Repository
+	pusher           realtime.Pusher
+	logger           *logger.LogrusLogger
+	rootPath         string
 }
 
-func NewPlaylistUsecase(repo playlist.Repository) playlist.Usecase {
+func NewPlaylistUsecase(repo playlist.Repository,
+	fRepo feed.Repository,
+	nRepo notifications.Repository,
+	pusher realtime.Pusher,
+	logger *logger.LogrusLogger) playlist.Usecase {
+	rootPath, _ := os.Getwd()
+
 	return &PlaylistUsecase{
-		playlistRepo: repo,
+		playlistRepo:     repo,
+		feedRepo:         fRepo,
+		notificationRepo: nRepo,
+		pusher:           pusher,
+		logger:           logger,
+		rootPath:         rootPath,
 	}
 }
 
@@ -21,11 +63,40 @@ func (pUC *PlaylistUsecase) Store(playlist *models.Playlist) error {
 		return err
 	}
 
+	pUC.recordActivity(playlist.OwnerID, ACTIVITY_PLAYLIST_CREATE, playlist.ID)
+
+	return nil
+}
+
+// Import creates the playlist already filled with the tracks,
+// an import that matched nothing is rejected
+func (pUC *PlaylistUsecase) Import(playlist *models.Playlist, trackIDs []uint64) error {
+	if len(trackIDs) == 0 {
+		return ErrBadParam
+	}
+
+	if err := pUC.playlistRepo.Import(playlist, trackIDs); err != nil {
+		return ErrInternalServerError
+	}
+
+	pUC.refreshCover(playlist.ID)
+	pUC.recordActivity(playlist.OwnerID, ACTIVITY_PLAYLIST_CREATE, playlist.ID)
+
 	return nil
 }
 
-func (pUC *PlaylistUsecase) Fetch(userID uint64, count uint64, offset uint64) ([]*models.Playlist, uint64, error) {
-	playlists, total, err := pUC.playlistRepo.Fetch(userID, count, offset)
+func (pUC *PlaylistUsecase) Fetch(userID uint64, viewer *models.User, count uint64, offset uint64) ([]*models.Playlist, uint64, error) {
+	var viewerID uint64
+
+	if viewer != nil {
+		viewerID = viewer.ID
+
+		if viewer.Role == ADMIN {
+			viewerID = userID
+		}
+	}
+
+	playlists, total, err := pUC.playlistRepo.Fetch(userID, viewerID, count, offset)
 
 	if err != nil {
 		return nil, total, err
@@ -35,21 +106,138 @@ func (pUC *PlaylistUsecase) Fetch(userID uint64, count uint64, offset uint64) ([
 		playlists = []*models.Playlist{}
 	}
 
+	if viewerID != userID {
+		for _, p := range playlists {
+			p.Link = ""
+		}
+	}
+
 	return playlists, total, nil
 }
 
-func (pUC *PlaylistUsecase) DeleteByID(playlistID uint64) error {
+func (pUC *PlaylistUsecase) FetchInvitations(userID uint64) ([]*models.Playlist, error) {
+	playlists, err := pUC.playlistRepo.FetchInvitations(userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if playlists == nil {
+		playlists = []*models.Playlist{}
+	}
+
+	return playlists, nil
+}
+
+// allowed checks the user's access level to the playlist:
+// owners and admins can do anything, accepted collaborators can view and edit
+// tracks, everyone can view public playlists. Pending invitations grant nothing,
+// the invited user sees the playlist among invitations only.
+func (pUC *PlaylistUsecase) allowed(p *models.Playlist, usr *models.User, level int) (bool, error) {
+	if usr != nil && (p.OwnerID == usr.ID || usr.Role == ADMIN) {
+		return true, nil
+	}
+
+	if level == manageAccess {
+		return false, nil
+	}
+
+	if level == viewAccess && p.Visibility == PLAYLIST_PUBLIC {
+		return true, nil
+	}
+
+	if usr == nil {
+		return false, nil
+	}
+
+	accepted, err := pUC.playlistRepo.GetCollaboration(p.ID, usr.ID)
+
+	if err == ErrNotFound {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return accepted, nil
+}
+
+// checkAccess returns the playlist if the user has the given level of access to it.
+// Playlists the user can't even view are reported as missing, so that
+// their existence isn't disclosed.
+func (pUC *PlaylistUsecase) checkAccess(playlistID uint64, usr *models.User, level int) (*models.Playlist, uint64, error) {
+	p, amountOfTracks, err := pUC.playlistRepo.GetSinglePlaylist(playlistID)
+
+	if err == ErrNotFound {
+		return nil, amountOfTracks, err
+	}
+
+	if err != nil {
+		return nil, amountOfTracks, ErrInternalServerError
+	}
+
+	ok, err := pUC.allowed(p, usr, level)
+
+	if err != nil {
+		return nil, amountOfTracks, ErrInternalServerError
+	}
+
+	if ok {
+		return p, amountOfTracks, nil
+	}
+
+	if level != viewAccess {
+		ok, err = pUC.allowed(p, usr, viewAccess)
+
+		if err != nil {
+			return nil, amountOfTracks, ErrInternalServerError
+		}
+	}
+
+	if !ok {
+		return nil, amountOfTracks, ErrNotFound
+	}
+
+	return nil, amountOfTracks, ErrPermissionDenied
+}
+
+// hideLink keeps the share link visible only to those who manage the playlist
+func (pUC *PlaylistUsecase) hideLink(p *models.Playlist, usr *models.User) {
+	if ok, _ := pUC.allowed(p, usr, manageAccess); !ok {
+		p.Link = ""
+	}
+}
+
+func (pUC *PlaylistUsecase) DeleteByID(playlistID uint64, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlistID, usr, manageAccess)
+
+	if err != nil {
+		return err
+	}
+
+	audience := pUC.audience(p)
+
 	if err := pUC.playlistRepo.DeleteByID(playlistID); err != nil {
 		return err
 	}
 
+	pUC.removeCover(p)
+	pUC.push(audience, playlistID, changeDelete)
+
 	return nil
 }
 
-func (pUC *PlaylistUsecase) AddToPlaylist(playlistID uint64, trackID uint64) error {
-	err := pUC.playlistRepo.AddToPlaylist(playlistID, trackID)
+func (pUC *PlaylistUsecase) Update(playlist *models.Playlist, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlist.ID, usr, manageAccess)
 
-	if err == ErrAlreadyExist {
+	if err != nil {
+		return err
+	}
+
+	err = pUC.playlistRepo.Update(playlist)
+
+	if err == ErrNotFound {
 		return err
 	}
 
@@ -57,11 +245,44 @@ func (pUC *PlaylistUsecase) AddToPlaylist(playlistID uint64, trackID uint64) err
 		return ErrInternalServerError
 	}
 
+	pUC.recordActivity(usr.ID, ACTIVITY_PLAYLIST_UPDATE, playlist.ID)
+	pUC.pushChange(p, changeInfo)
+
+	return nil
+}
+
+func (pUC *PlaylistUsecase) AddToPlaylist(playlistID uint64, trackID uint64, position *uint64, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlistID, usr, editAccess)
+
+	if err != nil {
+		return err
+	}
+
+	err = pUC.playlistRepo.AddToPlaylist(playlistID, trackID, position)
+
+	if err == ErrAlreadyExist || err == ErrNotFound {
+		return err
+	}
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	pUC.refreshCover(playlistID)
+	pUC.recordActivity(usr.ID, ACTIVITY_PLAYLIST_UPDATE, playlistID)
+	pUC.pushChange(p, changeTracks)
+
 	return nil
 }
 
-func (pUC *PlaylistUsecase) RemoveFromPlaylist(playlistID uint64, trackID uint64) error {
-	err := pUC.playlistRepo.RemoveFromPlaylist(playlistID, trackID)
+func (pUC *PlaylistUsecase) RemoveFromPlaylist(playlistID uint64, trackID uint64, position *uint64, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlistID, usr, editAccess)
+
+	if err != nil {
+		return err
+	}
+
+	err = pUC.playlistRepo.RemoveFromPlaylist(playlistID, trackID, position)
 
 	if err == ErrNotFound {
 		return err
@@ -71,29 +292,438 @@ func (pUC *PlaylistUsecase) RemoveFromPlaylist(playlistID uint64, trackID uint64
 		return ErrInternalServerError
 	}
 
+	pUC.refreshCover(playlistID)
+	pUC.pushChange(p, changeTracks)
+
 	return nil
 }
 
-func (pUC *PlaylistUsecase) GetSinglePlaylist(playlistID uint64) (*models.Playlist, uint64, error) {
-	p, amountOfTracks, err := pUC.playlistRepo.GetSinglePlaylist(playlistID)
+func (pUC *PlaylistUsecase) MoveTrack(playlistID uint64, from uint64, to uint64, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlistID, usr, editAccess)
+
+	if err != nil {
+		return err
+	}
+
+	if from == to {
+		return nil
+	}
+
+	err = pUC.playlistRepo.MoveTrack(playlistID, from, to)
+
+	if err == ErrBadParam {
+		return err
+	}
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	pUC.refreshCover(playlistID)
+	pUC.pushChange(p, changeTracks)
+
+	return nil
+}
+
+func (pUC *PlaylistUsecase) ReorderTracks(playlistID uint64, order []uint64, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlistID, usr, editAccess)
+
+	if err != nil {
+		return err
+	}
+
+	err = pUC.playlistRepo.ReorderTracks(playlistID, order)
+
+	if err == ErrBadParam {
+		return err
+	}
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	pUC.refreshCover(playlistID)
+	pUC.pushChange(p, changeTracks)
+
+	return nil
+}
+
+func (pUC *PlaylistUsecase) GetSinglePlaylist(playlistID uint64, usr *models.User) (*models.Playlist, uint64, error) {
+	p, amountOfTracks, err := pUC.checkAccess(playlistID, usr, viewAccess)
 
 	if err != nil {
 		return nil, amountOfTracks, err
 	}
 
+	pUC.hideLink(p, usr)
+
 	return p, amountOfTracks, nil
 }
 
-func (pUC *PlaylistUsecase) GetTracksFrom(playlistID uint64, authID uint64) ([]*models.Track, error) {
-	tracks, err := pUC.playlistRepo.GetTracksFrom(playlistID, authID)
+// GetByLink gives access to the playlist by its share link unless it is private
+func (pUC *PlaylistUsecase) GetByLink(link string, usr *models.User) (*models.Playlist, uint64, error) {
+	p, amountOfTracks, err := pUC.playlistRepo.GetByLink(link)
+
+	if err == ErrNotFound {
+		return nil, amountOfTracks, err
+	}
+
+	if err != nil {
+		return nil, amountOfTracks, ErrInternalServerError
+	}
+
+	if p.Visibility == PLAYLIST_PRIVATE {
+		if ok, err := pUC.allowed(p, usr, viewAccess); err != nil || !ok {
+			return nil, amountOfTracks, ErrNotFound
+		}
+	}
+
+	pUC.hideLink(p, usr)
+
+	return p, amountOfTracks, nil
+}
+
+func (pUC *PlaylistUsecase) GetTracksFrom(playlistID uint64, usr *models.User) ([]*models.Track, error) {
+	if _, _, err := pUC.checkAccess(playlistID, usr, viewAccess); err != nil {
+		return nil, err
+	}
+
+	return pUC.getTracks(playlistID, usr)
+}
+
+func (pUC *PlaylistUsecase) GetTracksByLink(link string, usr *models.User) ([]*models.Track, error) {
+	p, _, err := pUC.GetByLink(link, usr)
 
 	if err != nil {
 		return nil, err
 	}
 
+	return pUC.getTracks(p.ID, usr)
+}
+
+func (pUC *PlaylistUsecase) getTracks(playlistID uint64, usr *models.User) ([]*models.Track, error) {
+	var authID uint64
+	if usr != nil {
+		authID = usr.ID
+	}
+
+	tracks, err := pUC.playlistRepo.GetTracksFrom(playlistID, authID)
+
+	if err != nil {
+		return nil, ErrInternalServerError
+	}
+
 	if tracks == nil {
 		tracks = []*models.Track{}
 	}
 
 	return tracks, nil
 }
+
+// Like marks the playlist as liked by the user and lets the owner know
+func (pUC *PlaylistUsecase) Like(playlistID uint64, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlistID, usr, viewAccess)
+
+	if err != nil {
+		return err
+	}
+
+	err = pUC.playlistRepo.Like(playlistID, usr.ID)
+
+	if err == ErrAlreadyExist {
+		return err
+	}
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	if p.OwnerID != usr.ID {
+		if _, err := pUC.notificationRepo.Store(p.OwnerID, NOTIFICATION_LIKE, usr.ID, playlistID); err != nil {
+			pUC.logger.L.Error(err)
+		}
+	}
+
+	return nil
+}
+
+func (pUC *PlaylistUsecase) Unlike(playlistID uint64, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlistID, usr, viewAccess)
+
+	if err != nil {
+		return err
+	}
+
+	err = pUC.playlistRepo.Unlike(playlistID, usr.ID)
+
+	if err == ErrNotFound {
+		return err
+	}
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	if err := pUC.notificationRepo.Remove(p.OwnerID, NOTIFICATION_LIKE, usr.ID, playlistID); err != nil {
+		pUC.logger.L.Error(err)
+	}
+
+	return nil
+}
+
+func (pUC *PlaylistUsecase) InviteCollaborator(playlistID uint64, userID uint64, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlistID, usr, manageAccess)
+
+	if err != nil {
+		return err
+	}
+
+	if p.OwnerID == userID {
+		return ErrBadParam
+	}
+
+	err = pUC.playlistRepo.AddCollaborator(playlistID, userID)
+
+	if err == ErrAlreadyExist {
+		return err
+	}
+
+	if err != nil {
+		return ErrNotFound
+	}
+
+	if _, err := pUC.notificationRepo.Store(userID, NOTIFICATION_COLLABORATION, usr.ID, playlistID); err != nil {
+		pUC.logger.L.Error(err)
+	}
+
+	return nil
+}
+
+func (pUC *PlaylistUsecase) AcceptCollaboration(playlistID uint64, usr *models.User) error {
+	err := pUC.playlistRepo.AcceptCollaboration(playlistID, usr.ID)
+
+	if err == ErrNotFound {
+		return err
+	}
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	return nil
+}
+
+// RemoveCollaborator revokes the invitation, collaborators may also leave by themselves
+func (pUC *PlaylistUsecase) RemoveCollaborator(playlistID uint64, userID uint64, usr *models.User) error {
+	if usr.ID != userID {
+		if _, _, err := pUC.checkAccess(playlistID, usr, manageAccess); err != nil {
+			return err
+		}
+	}
+
+	err := pUC.playlistRepo.RemoveCollaborator(playlistID, userID)
+
+	if err == ErrNotFound {
+		return err
+	}
+
+	if err != nil {
+		return ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (pUC *PlaylistUsecase) GetCollaborators(playlistID uint64, usr *models.User) ([]*models.Collaborator, error) {
+	if _, _, err := pUC.checkAccess(playlistID, usr, viewAccess); err != nil {
+		return nil, err
+	}
+
+	collaborators, err := pUC.playlistRepo.GetCollaborators(playlistID)
+
+	if err != nil {
+		return nil, ErrInternalServerError
+	}
+
+	if collaborators == nil {
+		collaborators = []*models.Collaborator{}
+	}
+
+	return collaborators, nil
+}
+
+// CanManage tells whether the user may change the playlist settings and cover
+func (pUC *PlaylistUsecase) CanManage(playlistID uint64, usr *models.User) error {
+	_, _, err := pUC.checkAccess(playlistID, usr, manageAccess)
+
+	return err
+}
+
+func (pUC *PlaylistUsecase) UpdatePhoto(playlistID uint64, path string, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlistID, usr, manageAccess)
+
+	if err != nil {
+		return err
+	}
+
+	if err := pUC.playlistRepo.UpdatePhoto(playlistID, path, true); err != nil {
+		return ErrInternalServerError
+	}
+
+	pUC.removeCover(p)
+	pUC.pushChange(p, changePhoto)
+
+	return nil
+}
+
+// ResetPhoto drops the custom cover and returns to the generated one
+func (pUC *PlaylistUsecase) ResetPhoto(playlistID uint64, usr *models.User) error {
+	p, _, err := pUC.checkAccess(playlistID, usr, manageAccess)
+
+	if err != nil {
+		return err
+	}
+
+	if !p.CustomPhoto {
+		return nil
+	}
+
+	if err := pUC.playlistRepo.UpdatePhoto(playlistID, DEFAULT_PLAYLIST_PHOTO, false); err != nil {
+		return ErrInternalServerError
+	}
+
+	pUC.removeCover(p)
+	pUC.refreshCover(playlistID)
+	pUC.pushChange(p, changePhoto)
+
+	return nil
+}
+
+// refreshCover regenerates the cover of a playlist without a custom one:
+// a 2x2 mosaic of the first four distinct album covers, the only cover
+// when there are less of them, or the default one for an empty playlist.
+// Mosaics are named after their covers, so an unchanged set reuses the cached file.
+func (pUC *PlaylistUsecase) refreshCover(playlistID uint64) {
+	p, _, err := pUC.playlistRepo.GetSinglePlaylist(playlistID)
+
+	if err != nil {
+		pUC.logger.L.Error("Playlist cover: can't get playlist: ", err)
+		return
+	}
+
+	if p.CustomPhoto {
+		return
+	}
+
+	covers, err := pUC.playlistRepo.GetCovers(playlistID, 4)
+
+	if err != nil {
+		pUC.logger.L.Error("Playlist cover: can't get covers: ", err)
+		return
+	}
+
+	var photo string
+
+	switch {
+	case len(covers) == 0:
+		photo = DEFAULT_PLAYLIST_PHOTO
+	case len(covers) < 4:
+		photo = covers[0]
+	default:
+		hash := md5.Sum([]byte(strings.Join(covers, "|")))
+		photo = fmt.Sprintf("%smosaic-%d-%s.jpg", PLAYLISTS_PHOTOS_PATH, playlistID, hex.EncodeToString(hash[:]))
+
+		if _, err := os.Stat(filepath.Join(pUC.rootPath, photo)); os.IsNotExist(err) {
+			var paths [4]string
+			for i, cover := range covers {
+				paths[i] = filepath.Join(pUC.rootPath, cover)
+			}
+
+			if err := mosaic.Compose(paths, filepath.Join(pUC.rootPath, photo)); err != nil {
+				pUC.logger.L.Error("Playlist cover: can't compose mosaic: ", err)
+				return
+			}
+		}
+	}
+
+	if photo == p.Photo {
+		return
+	}
+
+	if err := pUC.playlistRepo.UpdatePhoto(playlistID, photo, false); err != nil {
+		pUC.logger.L.Error("Playlist cover: can't update photo: ", err)
+		return
+	}
+
+	pUC.removeGenerated(p.Photo)
+}
+
+// recordActivity adds the event to the feed; the feed isn't worth failing
+// the request, so errors are only logged. Non-public playlists are
+// filtered out when the feed is read.
+func (pUC *PlaylistUsecase) recordActivity(userID uint64, kind string, playlistID uint64) {
+	if err := pUC.feedRepo.Store(userID, kind, playlistID); err != nil {
+		pUC.logger.L.Error(err)
+	}
+}
+
+// audience returns the owner and accepted collaborators of the playlist,
+// the ones whose clients are told about its changes.
+func (pUC *PlaylistUsecase) audience(p *models.Playlist) []uint64 {
+	if !pUC.pusher.Online() {
+		return nil
+	}
+
+	ids := []uint64{p.OwnerID}
+	collaborators, err := pUC.playlistRepo.GetCollaborators(p.ID)
+
+	if err != nil {
+		pUC.logger.L.Error(err)
+		return ids
+	}
+
+	for _, c := range collaborators {
+		if c.Accepted {
+			ids = append(ids, c.UserID)
+		}
+	}
+
+	return ids
+}
+
+func (pUC *PlaylistUsecase) pushChange(p *models.Playlist, change string) {
+	pUC.push(pUC.audience(p), p.ID, change)
+}
+
+func (pUC *PlaylistUsecase) push(audience []uint64, playlistID uint64, change string) {
+	if len(audience) == 0 {
+		return
+	}
+
+	pUC.pusher.Push(audience, &models.Event{
+		Type: EVENT_PLAYLIST,
+		Body: map[string]interface{}{
+			"id":     playlistID,
+			"change": change,
+		},
+	})
+}
+
+// removeCover deletes the file of the playlist's current cover
+// unless it is shared with others, like album covers and the default one
+func (pUC *PlaylistUsecase) removeCover(p *models.Playlist) {
+	if !p.CustomPhoto {
+		pUC.removeGenerated(p.Photo)
+		return
+	}
+
+	if strings.HasPrefix(p.Photo, PLAYLISTS_PHOTOS_PATH) && p.Photo != DEFAULT_PLAYLIST_PHOTO {
+		os.Remove(filepath.Join(pUC.rootPath, p.Photo))
+	}
+}
+
+func (pUC *PlaylistUsecase) removeGenerated(photo string) {
+	if strings.HasPrefix(photo, PLAYLISTS_PHOTOS_PATH+"mosaic-") {
+		os.Remove(filepath.Join(pUC.rootPath, photo))
+	}
+}
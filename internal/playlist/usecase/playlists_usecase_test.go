@@ -0,0 +1,399 @@
+package usecase
+
+import (
+	"2019_2_Covenant/internal/feed"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/notifications"
+	"2019_2_Covenant/internal/playlist"
+	"2019_2_Covenant/pkg/logger"
+	. "2019_2_Covenant/tools/vars"
+	"io/ioutil"
+	"testing"
+)
+
+const (
+	ownerID = iota + 1
+	collaboratorID
+	invitedID
+	strangerID
+	adminID
+)
+
+const (
+	publicID = iota + 1
+	privateID
+	missingID = 99
+)
+
+const (
+	firstTrackID = iota + 10
+	secondTrackID
+	freeTrackID
+	unknownTrackID = 404
+)
+
+// fakeRepository keeps playlists in memory, methods the usecase doesn't
+// call are left to the embedded nil interface.
+type fakeRepository struct {
+	playlist.Repository
+	playlists     map[uint64]*models.Playlist
+	tracks        map[uint64][]uint64
+	collaborators map[uint64]map[uint64]bool
+	catalogue     map[uint64]bool
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		playlists: map[uint64]*models.Playlist{
+			publicID: {
+				ID:         publicID,
+				OwnerID:    ownerID,
+				Visibility: PLAYLIST_PUBLIC,
+				Photo:      DEFAULT_PLAYLIST_PHOTO,
+			},
+			privateID: {
+				ID:         privateID,
+				OwnerID:    ownerID,
+				Visibility: PLAYLIST_PRIVATE,
+				Photo:      DEFAULT_PLAYLIST_PHOTO,
+			},
+		},
+		tracks: map[uint64][]uint64{
+			publicID:  {firstTrackID, secondTrackID},
+			privateID: {firstTrackID, secondTrackID},
+		},
+		collaborators: map[uint64]map[uint64]bool{
+			privateID: {collaboratorID: true, invitedID: false},
+		},
+		catalogue: map[uint64]bool{
+			firstTrackID:  true,
+			secondTrackID: true,
+			freeTrackID:   true,
+		},
+	}
+}
+
+func (r *fakeRepository) GetSinglePlaylist(playlistID uint64) (*models.Playlist, uint64, error) {
+	p, ok := r.playlists[playlistID]
+
+	if !ok {
+		return nil, 0, ErrNotFound
+	}
+
+	copied := *p
+
+	return &copied, uint64(len(r.tracks[playlistID])), nil
+}
+
+func (r *fakeRepository) GetCollaboration(playlistID uint64, userID uint64) (bool, error) {
+	accepted, ok := r.collaborators[playlistID][userID]
+
+	if !ok {
+		return false, ErrNotFound
+	}
+
+	return accepted, nil
+}
+
+func (r *fakeRepository) GetCollaborators(playlistID uint64) ([]*models.Collaborator, error) {
+	var collaborators []*models.Collaborator
+
+	for id, accepted := range r.collaborators[playlistID] {
+		collaborators = append(collaborators, &models.Collaborator{UserID: id, Accepted: accepted})
+	}
+
+	return collaborators, nil
+}
+
+func (r *fakeRepository) DeleteByID(playlistID uint64) error {
+	delete(r.playlists, playlistID)
+	return nil
+}
+
+func (r *fakeRepository) Update(p *models.Playlist) error {
+	r.playlists[p.ID].Name = p.Name
+	return nil
+}
+
+func (r *fakeRepository) AddToPlaylist(playlistID uint64, trackID uint64, position *uint64) error {
+	if !r.catalogue[trackID] {
+		return ErrNotFound
+	}
+
+	for _, id := range r.tracks[playlistID] {
+		if id == trackID && !r.playlists[playlistID].AllowDuplicates {
+			return ErrAlreadyExist
+		}
+	}
+
+	r.tracks[playlistID] = append(r.tracks[playlistID], trackID)
+
+	return nil
+}
+
+func (r *fakeRepository) RemoveFromPlaylist(playlistID uint64, trackID uint64, position *uint64) error {
+	tracks := r.tracks[playlistID]
+
+	for i, id := range tracks {
+		if id == trackID && (position == nil || *position == uint64(i)) {
+			r.tracks[playlistID] = append(tracks[:i], tracks[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (r *fakeRepository) MoveTrack(playlistID uint64, from uint64, to uint64) error {
+	tracks := r.tracks[playlistID]
+
+	if from >= uint64(len(tracks)) || to >= uint64(len(tracks)) {
+		return ErrBadParam
+	}
+
+	tracks[from], tracks[to] = tracks[to], tracks[from]
+
+	return nil
+}
+
+func (r *fakeRepository) GetTracksFrom(playlistID uint64, authID uint64) ([]*models.Track, error) {
+	var tracks []*models.Track
+
+	for _, id := range r.tracks[playlistID] {
+		tracks = append(tracks, &models.Track{ID: id})
+	}
+
+	return tracks, nil
+}
+
+func (r *fakeRepository) UpdatePhoto(playlistID uint64, path string, custom bool) error {
+	r.playlists[playlistID].Photo = path
+	r.playlists[playlistID].CustomPhoto = custom
+	return nil
+}
+
+func (r *fakeRepository) GetCovers(playlistID uint64, count uint64) ([]string, error) {
+	return nil, nil
+}
+
+type fakeFeed struct {
+	feed.Repository
+}
+
+func (fakeFeed) Store(userID uint64, kind string, objectID uint64) error {
+	return nil
+}
+
+type fakeNotifications struct {
+	notifications.Repository
+}
+
+type fakePusher struct{}
+
+func (fakePusher) Online() bool {
+	return false
+}
+
+func (fakePusher) Push(userIDs []uint64, event *models.Event) {}
+
+func newTestUsecase() (*PlaylistUsecase, *fakeRepository) {
+	repo := newFakeRepository()
+	log := logger.NewLogrusLogger()
+	log.L.Out = ioutil.Discard
+
+	uc := NewPlaylistUsecase(repo, fakeFeed{}, fakeNotifications{}, fakePusher{}, log).(*PlaylistUsecase)
+	uc.rootPath = ""
+
+	return uc, repo
+}
+
+func user(id uint64) *models.User {
+	if id == adminID {
+		return &models.User{ID: id, Role: ADMIN}
+	}
+
+	return &models.User{ID: id, Role: USER}
+}
+
+// Every route of the playlist handler in terms of the usecase
+var actions = map[string]func(uc *PlaylistUsecase, playlistID uint64, usr *models.User) error{
+	"delete": func(uc *PlaylistUsecase, playlistID uint64, usr *models.User) error {
+		return uc.DeleteByID(playlistID, usr)
+	},
+	"update": func(uc *PlaylistUsecase, playlistID uint64, usr *models.User) error {
+		return uc.Update(&models.Playlist{ID: playlistID, Name: "renamed"}, usr)
+	},
+	"photo": func(uc *PlaylistUsecase, playlistID uint64, usr *models.User) error {
+		return uc.UpdatePhoto(playlistID, PLAYLISTS_PHOTOS_PATH+"cover.jpg", usr)
+	},
+	"add": func(uc *PlaylistUsecase, playlistID uint64, usr *models.User) error {
+		return uc.AddToPlaylist(playlistID, freeTrackID, nil, usr)
+	},
+	"remove": func(uc *PlaylistUsecase, playlistID uint64, usr *models.User) error {
+		return uc.RemoveFromPlaylist(playlistID, firstTrackID, nil, usr)
+	},
+	"move": func(uc *PlaylistUsecase, playlistID uint64, usr *models.User) error {
+		return uc.MoveTrack(playlistID, 0, 1, usr)
+	},
+	"get": func(uc *PlaylistUsecase, playlistID uint64, usr *models.User) error {
+		_, _, err := uc.GetSinglePlaylist(playlistID, usr)
+		return err
+	},
+	"tracks": func(uc *PlaylistUsecase, playlistID uint64, usr *models.User) error {
+		_, err := uc.GetTracksFrom(playlistID, usr)
+		return err
+	},
+}
+
+func TestPlaylistUsecase_Access(t *testing.T) {
+	cases := []struct {
+		name       string
+		playlistID uint64
+		userID     uint64
+		want       map[string]error
+	}{
+		{
+			name:       "owner",
+			playlistID: privateID,
+			userID:     ownerID,
+			want:       map[string]error{},
+		},
+		{
+			name:       "admin",
+			playlistID: privateID,
+			userID:     adminID,
+			want:       map[string]error{},
+		},
+		{
+			name:       "non-owner of public playlist",
+			playlistID: publicID,
+			userID:     strangerID,
+			want: map[string]error{
+				"delete": ErrPermissionDenied,
+				"update": ErrPermissionDenied,
+				"photo":  ErrPermissionDenied,
+				"add":    ErrPermissionDenied,
+				"remove": ErrPermissionDenied,
+				"move":   ErrPermissionDenied,
+			},
+		},
+		{
+			name:       "accepted collaborator",
+			playlistID: privateID,
+			userID:     collaboratorID,
+			want: map[string]error{
+				"delete": ErrPermissionDenied,
+				"update": ErrPermissionDenied,
+				"photo":  ErrPermissionDenied,
+			},
+		},
+		{
+			name:       "invited collaborator",
+			playlistID: privateID,
+			userID:     invitedID,
+			want: map[string]error{
+				"delete": ErrNotFound,
+				"update": ErrNotFound,
+				"photo":  ErrNotFound,
+				"add":    ErrNotFound,
+				"remove": ErrNotFound,
+				"move":   ErrNotFound,
+				"get":    ErrNotFound,
+				"tracks": ErrNotFound,
+			},
+		},
+		{
+			name:       "non-owner of private playlist",
+			playlistID: privateID,
+			userID:     strangerID,
+			want: map[string]error{
+				"delete": ErrNotFound,
+				"update": ErrNotFound,
+				"photo":  ErrNotFound,
+				"add":    ErrNotFound,
+				"remove": ErrNotFound,
+				"move":   ErrNotFound,
+				"get":    ErrNotFound,
+				"tracks": ErrNotFound,
+			},
+		},
+		{
+			name:       "missing playlist",
+			playlistID: missingID,
+			userID:     ownerID,
+			want: map[string]error{
+				"delete": ErrNotFound,
+				"update": ErrNotFound,
+				"photo":  ErrNotFound,
+				"add":    ErrNotFound,
+				"remove": ErrNotFound,
+				"move":   ErrNotFound,
+				"get":    ErrNotFound,
+				"tracks": ErrNotFound,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		for action, do := range actions {
+			uc, _ := newTestUsecase()
+
+			if err := do(uc, c.playlistID, user(c.userID)); err != c.want[action] {
+				t.Errorf("%s, %s: got %v, want %v", c.name, action, err, c.want[action])
+			}
+		}
+	}
+}
+
+func TestPlaylistUsecase_AnonymousViewer(t *testing.T) {
+	uc, _ := newTestUsecase()
+
+	if _, _, err := uc.GetSinglePlaylist(publicID, nil); err != nil {
+		t.Errorf("public playlist: got %v, want nil", err)
+	}
+
+	if _, _, err := uc.GetSinglePlaylist(privateID, nil); err != ErrNotFound {
+		t.Errorf("private playlist: got %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestPlaylistUsecase_AddToPlaylist(t *testing.T) {
+	cases := []struct {
+		name    string
+		trackID uint64
+		want    error
+	}{
+		{"new track", freeTrackID, nil},
+		{"duplicate", firstTrackID, ErrAlreadyExist},
+		{"unknown track", unknownTrackID, ErrNotFound},
+	}
+
+	for _, c := range cases {
+		uc, repo := newTestUsecase()
+
+		if err := uc.AddToPlaylist(publicID, c.trackID, nil, user(ownerID)); err != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+
+		if c.want == nil && len(repo.tracks[publicID]) != 3 {
+			t.Errorf("%s: track isn't added", c.name)
+		}
+	}
+}
+
+func TestPlaylistUsecase_HideLink(t *testing.T) {
+	uc, repo := newTestUsecase()
+	repo.playlists[publicID].Link = "secret"
+
+	p, _, err := uc.GetSinglePlaylist(publicID, user(strangerID))
+
+	if err != nil || p.Link != "" {
+		t.Errorf("stranger: got link %q, error %v", p.Link, err)
+	}
+
+	p, _, err = uc.GetSinglePlaylist(publicID, user(ownerID))
+
+	if err != nil || p.Link != "secret" {
+		t.Errorf("owner: got link %q, error %v", p.Link, err)
+	}
+}
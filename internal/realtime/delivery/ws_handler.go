@@ -0,0 +1,108 @@
+package delivery
+
+import (
+	"2019_2_Covenant/internal/middlewares"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/realtime"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/reader"
+	. "2019_2_Covenant/tools/base_handler"
+	. "2019_2_Covenant/tools/response"
+	. "2019_2_Covenant/tools/vars"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+	"net/http"
+)
+
+type WSHandler struct {
+	BaseHandler
+	Hub *realtime.Hub
+}
+
+func NewWSHandler(hub *realtime.Hub,
+	mManager *middlewares.MiddlewareManager,
+	logger *logger.LogrusLogger) *WSHandler {
+	return &WSHandler{
+		BaseHandler: BaseHandler{
+			MManager:  mManager,
+			Logger:    logger,
+			ReqReader: reader.NewReqReader(),
+		},
+		Hub: hub,
+	}
+}
+
+func (wh *WSHandler) Configure(e *echo.Echo) {
+	e.GET("/api/v1/ws", wh.Connect(), wh.MManager.CheckAuthStrictly)
+}
+
+// Connect upgrades the connection to WebSocket and pushes the user's
+// events to it until the client disconnects. Messages from the client
+// are ignored.
+func (wh *WSHandler) Connect() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			wh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		server := websocket.Server{
+			// Browsers always send Origin, so a foreign one means somebody
+			// else's page is trying to use the user's cookie.
+			Handshake: func(config *websocket.Config, r *http.Request) error {
+				if origin := r.Header.Get("Origin"); origin != "" && !wh.MManager.AllowedOrigin(origin) {
+					return ErrPermissionDenied
+				}
+
+				return nil
+			},
+			Handler: func(ws *websocket.Conn) {
+				wh.serve(ws, sess.UserID, sess.ID)
+			},
+		}
+
+		server.ServeHTTP(c.Response(), c.Request())
+
+		return nil
+	}
+}
+
+// serve returns when either side disconnects or the session ends
+// and the hub closes the client.
+func (wh *WSHandler) serve(ws *websocket.Conn, userID uint64, sessionID uint64) {
+	client := wh.Hub.Register(userID, sessionID)
+	defer wh.Hub.Unregister(client)
+
+	closed := make(chan struct{})
+
+	go func() {
+		defer close(closed)
+
+		var msg string
+
+		for {
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-client.Events():
+			if !ok {
+				return
+			}
+
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package realtime
+
+import (
+	"2019_2_Covenant/internal/feed"
+	"2019_2_Covenant/internal/models"
+	. "2019_2_Covenant/tools/vars"
+	"fmt"
+)
+
+// FeedRepository pushes stored activities to the connected followers
+// who are allowed to see them. An error is returned if the activity is
+// stored but can't be pushed.
+type FeedRepository struct {
+	feed.Repository
+	pusher Pusher
+}
+
+func NewFeedRepository(repo feed.Repository, pusher Pusher) feed.Repository {
+	return &FeedRepository{
+		Repository: repo,
+		pusher:     pusher,
+	}
+}
+
+func (fR *FeedRepository) Store(userID uint64, kind string, objectID uint64) error {
+	if err := fR.Repository.Store(userID, kind, objectID); err != nil {
+		return err
+	}
+
+	if !fR.pusher.Online() {
+		return nil
+	}
+
+	audience, err := fR.Repository.FetchAudience(userID, kind, objectID)
+
+	if err != nil {
+		return fmt.Errorf("activity is stored but not pushed: %v", err)
+	}
+
+	fR.pusher.Push(audience, &models.Event{
+		Type: EVENT_ACTIVITY,
+		Body: map[string]interface{}{
+			"type":      kind,
+			"user_id":   userID,
+			"object_id": objectID,
+		},
+	})
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package realtime
+
+import (
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/pkg/logger"
+	"sync"
+)
+
+// Events are buffered per connection, a client that doesn't keep up
+// misses the events that don't fit.
+const clientBuffer = 32
+
+type Pusher interface {
+	Online() bool
+	Push(userIDs []uint64, event *models.Event)
+}
+
+// Disconnector closes connections opened with sessions that have ended.
+type Disconnector interface {
+	CloseSession(sessionID uint64)
+	CloseOtherSessions(userID uint64, currentID uint64)
+	CloseUser(userID uint64)
+}
+
+// Client is a single connection of a user, a user may have many of them.
+// Connections authenticated by API tokens have no session.
+type Client struct {
+	UserID    uint64
+	SessionID uint64
+	send      chan *models.Event
+}
+
+func (c *Client) Events() <-chan *models.Event {
+	return c.send
+}
+
+// Hub delivers events to connected clients of the process.
+type Hub struct {
+	mu       sync.RWMutex
+	clients  map[uint64]map[*Client]struct{}
+	sessions map[uint64]map[*Client]struct{}
+	logger   *logger.LogrusLogger
+}
+
+func NewHub(logger *logger.LogrusLogger) *Hub {
+	return &Hub{
+		clients:  map[uint64]map[*Client]struct{}{},
+		sessions: map[uint64]map[*Client]struct{}{},
+		logger:   logger,
+	}
+}
+
+// Register adds a connection of the user opened with the session,
+// sessionID is 0 for connections authenticated otherwise.
+func (h *Hub) Register(userID uint64, sessionID uint64) *Client {
+	c := &Client{
+		UserID:    userID,
+		SessionID: sessionID,
+		send:      make(chan *models.Event, clientBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	add(h.clients, userID, c)
+
+	if sessionID != 0 {
+		add(h.sessions, sessionID, c)
+	}
+
+	return c
+}
+
+// Unregister removes the client and closes its channel of events.
+func (h *Hub) Unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(c)
+}
+
+// CloseSession disconnects the clients connected with the session.
+func (h *Hub) CloseSession(sessionID uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.sessions[sessionID] {
+		h.remove(c)
+	}
+}
+
+// CloseOtherSessions disconnects the user's clients connected with
+// any session but the current one.
+func (h *Hub) CloseOtherSessions(userID uint64, currentID uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.clients[userID] {
+		if c.SessionID != 0 && c.SessionID != currentID {
+			h.remove(c)
+		}
+	}
+}
+
+// CloseUser disconnects all the clients of the user.
+func (h *Hub) CloseUser(userID uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.clients[userID] {
+		h.remove(c)
+	}
+}
+
+// remove must be called with the lock held. Closing the channel of events
+// makes the connection serving the client end.
+func (h *Hub) remove(c *Client) {
+	conns, ok := h.clients[c.UserID]
+
+	if !ok {
+		return
+	}
+
+	if _, ok := conns[c]; !ok {
+		return
+	}
+
+	delete(conns, c)
+	close(c.send)
+
+	if len(conns) == 0 {
+		delete(h.clients, c.UserID)
+	}
+
+	if conns, ok := h.sessions[c.SessionID]; ok {
+		delete(conns, c)
+
+		if len(conns) == 0 {
+			delete(h.sessions, c.SessionID)
+		}
+	}
+}
+
+func add(index map[uint64]map[*Client]struct{}, key uint64, c *Client) {
+	if index[key] == nil {
+		index[key] = map[*Client]struct{}{}
+	}
+
+	index[key][c] = struct{}{}
+}
+
+// Online reports whether anybody is connected, so that pushers can skip
+// looking up recipients of events nobody would receive.
+func (h *Hub) Online() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.clients) != 0
+}
+
+func (h *Hub) Push(userIDs []uint64, event *models.Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, id := range userIDs {
+		for c := range h.clients[id] {
+			select {
+			case c.send <- event:
+			default:
+				h.logger.L.Warn("realtime: dropping event for a slow client of user ", id)
+			}
+		}
+	}
+}
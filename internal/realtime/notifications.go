@@ -0,0 +1,50 @@
+package realtime
+
+import (
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/notifications"
+	. "2019_2_Covenant/tools/vars"
+	"fmt"
+)
+
+// NotificationsRepository pushes stored notifications to the connected
+// clients of the recipient along with the new unread count. An error is
+// returned along with the id if the notification is stored but can't be pushed.
+type NotificationsRepository struct {
+	notifications.Repository
+	pusher Pusher
+}
+
+func NewNotificationsRepository(repo notifications.Repository, pusher Pusher) notifications.Repository {
+	return &NotificationsRepository{
+		Repository: repo,
+		pusher:     pusher,
+	}
+}
+
+func (nR *NotificationsRepository) Store(userID uint64, kind string, actorID uint64, playlistID uint64) (uint64, error) {
+	id, err := nR.Repository.Store(userID, kind, actorID, playlistID)
+
+	if err != nil || id == 0 || !nR.pusher.Online() {
+		return id, err
+	}
+
+	unread, err := nR.Repository.CountUnread(userID)
+
+	if err != nil {
+		return id, fmt.Errorf("notification is stored but not pushed: %v", err)
+	}
+
+	nR.pusher.Push([]uint64{userID}, &models.Event{
+		Type: EVENT_NOTIFICATION,
+		Body: map[string]interface{}{
+			"id":          id,
+			"type":        kind,
+			"actor_id":    actorID,
+			"playlist_id": playlistID,
+			"unread":      unread,
+		},
+	})
+
+	return id, nil
+}
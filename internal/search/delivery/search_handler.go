@@ -4,7 +4,6 @@ import (
 	"2019_2_Covenant/internal/middlewares"
 	"2019_2_Covenant/internal/models"
 	"2019_2_Covenant/internal/search"
-	"2019_2_Covenant/internal/user"
 	"2019_2_Covenant/pkg/logger"
 	"2019_2_Covenant/pkg/reader"
 	. "2019_2_Covenant/tools/base_handler"
@@ -13,15 +12,16 @@ import (
 	. "2019_2_Covenant/tools/vars"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type SearchHandler struct {
 	BaseHandler
 	SUsecase search.Usecase
-	UUsecase user.Usecase
 }
 
-func NewSearchHandler(sUC search.Usecase, uUC user.Usecase,
+func NewSearchHandler(sUC search.Usecase,
 	mManager *middlewares.MiddlewareManager,
 	logger *logger.LogrusLogger) *SearchHandler {
 	return &SearchHandler{
@@ -31,79 +31,342 @@ func NewSearchHandler(sUC search.Usecase, uUC user.Usecase,
 			ReqReader: reader.NewReqReader(),
 		},
 		SUsecase: sUC,
-		UUsecase: uUC,
 	}
 }
 
 func (sh *SearchHandler) Configure(e *echo.Echo) {
+	write := sh.MManager.TokenScope(TOKEN_SCOPE_LIBRARY_WRITE)
+
 	e.GET("/api/v1/search", sh.Search(), sh.MManager.CheckAuth)
+	e.GET("/api/v1/search/suggest", sh.Suggest(), sh.MManager.CheckAuth)
+	e.GET("/api/v1/search/trending", sh.GetTrending(), sh.MManager.CheckAuth)
+	e.GET("/api/v1/search/history", sh.GetHistory(), sh.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/search/history", sh.ClearHistory(), write, sh.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/search/history/:id", sh.RemoveFromHistory(), write, sh.MManager.CheckAuthStrictly)
+}
+
+// isUserSearching reports whether the query addresses users ("@nickname")
+// and returns it without the leading "@".
+func isUserSearching(text string) (string, bool) {
+	if strings.HasPrefix(text, "@") {
+		return text[1:], true
+	}
+
+	return text, false
 }
 
 func (sh *SearchHandler) Search() echo.HandlerFunc {
 	type Request struct {
-		Search  string `query:"s"`
+		Search       string `query:"s" validate:"required"`
+		Count        uint64 `query:"count" validate:"omitempty,max=100"`
+		Offset       uint64 `query:"offset"`
+		Type         string `query:"type" validate:"omitempty,oneof=track album artist user playlist"`
+		YearFrom     uint64 `query:"year_from"`
+		YearTo       uint64 `query:"year_to"`
+		DurationFrom uint64 `query:"duration_from"`
+		DurationTo   uint64 `query:"duration_to"`
+		Favourites   bool   `query:"favourites"`
 	}
 
-	isUserSearching := func(req *Request) bool {
-		if req.Search[0] == '@' {
-			req.Search = req.Search[1:]
-			return true
-		}
-		return false
+	correctRanges := func(r interface{}) bool {
+		req := r.(*Request)
+		return (req.YearTo == 0 || req.YearFrom <= req.YearTo) &&
+			(req.DurationTo == 0 || req.DurationFrom <= req.DurationTo)
 	}
 
 	return func(c echo.Context) error {
 		request := &Request{}
 
-		if err := sh.ReqReader.Read(c, request, nil); err != nil {
+		if err := sh.ReqReader.Read(c, request, correctRanges); err != nil {
 			sh.Logger.Log(c, "info", "Invalid request.", err.Error())
 			return c.JSON(http.StatusBadRequest, Response{
 				Error: err.Error(),
 			})
 		}
 
-		body := &Body{}
+		if request.Count == 0 {
+			request.Count = 10
+		}
 
-		if isUserSearching(request) {
-			usr, err := sh.UUsecase.FindLike(request.Search, 10)
-			if err != nil {
-				sh.Logger.Log(c, "info", "Error while searching user.", err)
-				return c.JSON(http.StatusNotFound, Response{
-					Error: ErrNotFound.Error(),
-				})
-			}
+		var authID uint64
+		if sess, ok := c.Get("session").(*models.Session); ok {
+			authID = sess.UserID
+		}
 
-			body = &Body{
-				"user": usr,
-			}
+		filter := &models.SearchFilter{
+			YearFrom:     request.YearFrom,
+			YearTo:       request.YearTo,
+			DurationFrom: request.DurationFrom,
+			DurationTo:   request.DurationTo,
+			Favourites:   request.Favourites,
+		}
+
+		text, users := isUserSearching(request.Search)
+		var types []string
+
+		// Without an explicit type only the types the filters apply to are searched
+		if users {
+			types = []string{SEARCH_USER}
+		} else if request.Type != "" {
+			types = []string{request.Type}
 		} else {
-			var authID uint64
-			if sess, ok := c.Get("session").(*models.Session); ok {
-				authID = sess.UserID
+			for _, t := range []string{SEARCH_TRACK, SEARCH_ALBUM, SEARCH_ARTIST, SEARCH_PLAYLIST} {
+				if filter.AppliesTo(t) {
+					types = append(types, t)
+				}
 			}
+		}
 
-			tracks, albums, artists, err := sh.SUsecase.Search(request.Search, 10, authID)
+		result, err := sh.SUsecase.Search(text, types, filter, request.Count, request.Offset, authID)
 
-			if err != nil {
-				sh.Logger.Log(c, "info", "Error while searching.", err)
+		if err != nil {
+			sh.Logger.Log(c, "info", "Error while searching.", err)
+
+			switch err {
+			case ErrBadParam:
+				return c.JSON(http.StatusBadRequest, Response{
+					Error: err.Error(),
+				})
+			case ErrUnathorized:
+				return c.JSON(http.StatusUnauthorized, Response{
+					Error: err.Error(),
+				})
+			case ErrNotFound:
 				return c.JSON(http.StatusNotFound, Response{
-					Error: ErrNotFound.Error(),
+					Error: err.Error(),
 				})
 			}
 
-			for _, item := range tracks {
-				item.Duration = time_parser.GetDuration(item.Duration)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		for _, item := range result.Tracks {
+			item.Duration = time_parser.GetDuration(item.Duration)
+		}
+
+		body := Body{
+			"facets": result.Facets,
+		}
+
+		if result.Tracks != nil {
+			body["tracks"] = result.Tracks
+		}
+
+		if result.Albums != nil {
+			body["albums"] = result.Albums
+		}
+
+		if result.Artists != nil {
+			body["artists"] = result.Artists
+		}
+
+		if result.Users != nil {
+			body["user"] = result.Users
+		}
+
+		if result.Playlists != nil {
+			body["playlists"] = result.Playlists
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &body,
+		})
+	}
+}
+
+func (sh *SearchHandler) Suggest() echo.HandlerFunc {
+	type Request struct {
+		Query string `query:"q" validate:"required"`
+		Count uint64 `query:"count" validate:"omitempty,max=20"`
+	}
+
+	return func(c echo.Context) error {
+		request := &Request{}
+
+		if err := sh.ReqReader.Read(c, request, nil); err != nil {
+			sh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if request.Count == 0 {
+			request.Count = 8
+		}
+
+		var authID uint64
+		if sess, ok := c.Get("session").(*models.Session); ok {
+			authID = sess.UserID
+		}
+
+		text, users := isUserSearching(request.Query)
+
+		suggestions, err := sh.SUsecase.Suggest(c.Request().Context(), text, users, request.Count, authID)
+
+		if err != nil {
+			if err == ErrBadParam {
+				return c.JSON(http.StatusBadRequest, Response{
+					Error: err.Error(),
+				})
 			}
 
-			body = &Body{
-				"tracks": tracks,
-				"albums": albums,
-				"artists": artists,
+			sh.Logger.Log(c, "error", "Error while getting suggestions.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"suggestions": suggestions,
+			},
+		})
+	}
+}
+
+func (sh *SearchHandler) GetTrending() echo.HandlerFunc {
+	type Request struct {
+		Count uint64 `query:"count" validate:"omitempty,max=50"`
+	}
+
+	return func(c echo.Context) error {
+		request := &Request{}
+
+		if err := sh.ReqReader.Read(c, request, nil); err != nil {
+			sh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if request.Count == 0 {
+			request.Count = 10
+		}
+
+		queries, err := sh.SUsecase.FetchTrending(request.Count)
+
+		if err != nil {
+			sh.Logger.Log(c, "error", "Error while fetching trending queries.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"trending": queries,
+			},
+		})
+	}
+}
+
+func (sh *SearchHandler) GetHistory() echo.HandlerFunc {
+	type Request struct {
+		Count uint64 `query:"count" validate:"omitempty,max=50"`
+	}
+
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			sh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := sh.ReqReader.Read(c, request, nil); err != nil {
+			sh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if request.Count == 0 {
+			request.Count = 10
+		}
+
+		queries, err := sh.SUsecase.FetchHistory(sess.UserID, request.Count)
+
+		if err != nil {
+			sh.Logger.Log(c, "error", "Error while fetching search history.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"history": queries,
+			},
+		})
+	}
+}
+
+func (sh *SearchHandler) RemoveFromHistory() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			sh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		qID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			sh.Logger.Log(c, "info", "Atoi error.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		if err := sh.SUsecase.DeleteHistoryItem(sess.UserID, uint64(qID)); err != nil {
+			if err == ErrNotFound {
+				sh.Logger.Log(c, "info", "Search history item not found.", qID)
+				return c.JSON(http.StatusNotFound, Response{
+					Error: err.Error(),
+				})
 			}
+
+			sh.Logger.Log(c, "error", "Error while removing search history item.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (sh *SearchHandler) ClearHistory() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			sh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := sh.SUsecase.ClearHistory(sess.UserID); err != nil {
+			sh.Logger.Log(c, "error", "Error while clearing search history.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
 		}
 
 		return c.JSON(http.StatusOK, Response{
-			Body: body,
+			Message: "success",
 		})
 	}
 }
@@ -0,0 +1,27 @@
+package search
+
+import (
+	"2019_2_Covenant/internal/models"
+	"context"
+	"time"
+)
+
+type Repository interface {
+	FindTracks(variants []string, filter *models.SearchFilter, count uint64, offset uint64, authID uint64) ([]*models.Track, error)
+	CountTracks(variants []string, filter *models.SearchFilter, authID uint64) (uint64, error)
+	FindAlbums(variants []string, filter *models.SearchFilter, count uint64, offset uint64) ([]*models.Album, error)
+	CountAlbums(variants []string, filter *models.SearchFilter) (uint64, error)
+	FindArtists(variants []string, count uint64, offset uint64) ([]*models.Artist, error)
+	CountArtists(variants []string) (uint64, error)
+	FindUsers(variants []string, count uint64, offset uint64, authID uint64) ([]*models.User, error)
+	CountUsers(variants []string, authID uint64) (uint64, error)
+	Suggest(ctx context.Context, variants []string, count uint64) ([]*models.Suggestion, error)
+	SuggestUsers(ctx context.Context, variants []string, count uint64, authID uint64) ([]*models.Suggestion, error)
+	StoreQuery(userID uint64, query string, keep uint64) error
+	CountQuery(userID uint64, query string) error
+	FetchHistory(userID uint64, count uint64) ([]*models.SearchQuery, error)
+	DeleteHistoryItem(userID uint64, itemID uint64) error
+	ClearHistory(userID uint64) error
+	FetchTrending(since time.Time, minUsers uint64, count uint64) ([]*models.TrendingQuery, error)
+	PruneTrends(before time.Time) error
+}
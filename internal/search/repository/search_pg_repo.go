@@ -0,0 +1,668 @@
+package repository
+
+import (
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/search"
+	. "2019_2_Covenant/tools/search_query"
+	. "2019_2_Covenant/tools/vars"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Results are matched by substring, full-text and trigram word similarity
+// against every query variant, so small misspellings, transliteration and
+// wrong keyboard layout are tolerated. Relevance is blended with popularity
+// taken from the all-time chart: score / (score + 10) stays below 1, so it
+// can only reorder results of close relevance.
+
+type SearchRepository struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) search.Repository {
+	return &SearchRepository{
+		db: db,
+	}
+}
+
+func yearCondition(args *Args, column string, filter *models.SearchFilter) string {
+	var cond string
+
+	if filter.YearFrom != 0 {
+		cond += fmt.Sprintf(" AND extract(year from %s) >= %s", column, args.Add(filter.YearFrom))
+	}
+
+	if filter.YearTo != 0 {
+		cond += fmt.Sprintf(" AND extract(year from %s) <= %s", column, args.Add(filter.YearTo))
+	}
+
+	return cond
+}
+
+// trackCondition returns the filtering condition and the placeholders
+// of the query variants for ranking.
+func trackCondition(args *Args, variants []string, filter *models.SearchFilter, authID uint64) (string, []string) {
+	params := args.AddVariants(variants)
+
+	cond := fmt.Sprintf("(%s OR %s)", Match("T.name", params, true), Match("Ar.name", params, true))
+	cond += yearCondition(args, "Al.year", filter)
+
+	if filter.DurationFrom != 0 {
+		cond += " AND extract(epoch from T.duration) >= " + args.Add(filter.DurationFrom)
+	}
+
+	if filter.DurationTo != 0 {
+		cond += " AND extract(epoch from T.duration) <= " + args.Add(filter.DurationTo)
+	}
+
+	if filter.Favourites {
+		cond += " AND T.id in (select track_id from favourites where user_id = " + args.Add(authID) + ")"
+	}
+
+	return cond, params
+}
+
+func albumCondition(args *Args, variants []string, filter *models.SearchFilter) (string, []string) {
+	params := args.AddVariants(variants)
+
+	cond := fmt.Sprintf("(%s OR %s)", Match("Al.name", params, true), Match("Ar.name", params, true))
+
+	return cond + yearCondition(args, "Al.year", filter), params
+}
+
+func (sr *SearchRepository) count(query string, args Args) (uint64, error) {
+	var total uint64
+
+	if err := sr.db.QueryRow(query, args...).Scan(&total); err != nil {
+		return total, err
+	}
+
+	return total, nil
+}
+
+func (sr *SearchRepository) CountTracks(variants []string, filter *models.SearchFilter, authID uint64) (uint64, error) {
+	args := &Args{}
+	cond, _ := trackCondition(args, variants, filter, authID)
+
+	return sr.count("SELECT COUNT(*) FROM tracks T "+
+		"JOIN albums Al ON T.album_id = Al.id "+
+		"JOIN artists Ar ON Al.artist_id = Ar.id "+
+		"WHERE "+cond,
+		*args)
+}
+
+func (sr *SearchRepository) FindTracks(variants []string, filter *models.SearchFilter, count uint64, offset uint64, authID uint64) ([]*models.Track, error) {
+	var tracks []*models.Track
+
+	args := &Args{}
+	auth := args.Add(authID)
+	cond, params := trackCondition(args, variants, filter, authID)
+
+	rows, err := sr.db.Query(fmt.Sprintf(
+		"SELECT T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, "+
+			"T.id in (select track_id from favourites where user_id = %s) AS favourite, "+
+			"T.id in (select track_id from likes where user_id = %s) AS liked FROM tracks T "+
+			"JOIN albums Al ON T.album_id = Al.id "+
+			"JOIN artists Ar ON Al.artist_id = Ar.id "+
+			"LEFT JOIN track_charts C ON C.track_id = T.id AND C.period = 'all' "+
+			"WHERE %s "+
+			"ORDER BY 0.8 * greatest(%s, 0.9 * %s) + 0.2 * %s + "+
+			"0.2 * coalesce(C.score, 0) / (coalesce(C.score, 0) + 10.0) DESC, T.id "+
+			"LIMIT %s OFFSET %s",
+		auth,
+		auth,
+		cond,
+		Similarity("T.name", params),
+		Similarity("Ar.name", params),
+		TextRank("T.name", params),
+		args.Add(count),
+		args.Add(offset),
+	), *args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		t := &models.Track{}
+		isFavourite := new(bool)
+		isLiked := new(bool)
+
+		if err := rows.Scan(&t.ID, &t.AlbumID, &t.ArtistID, &t.Name, &t.Duration,
+			&t.Photo, &t.Artist, &t.Album, &t.Path, isFavourite, isLiked,
+		); err != nil {
+			return nil, err
+		}
+
+		if authID != 0 {
+			t.IsFavourite = isFavourite
+			t.IsLiked = isLiked
+		}
+
+		tracks = append(tracks, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tracks, nil
+}
+
+func (sr *SearchRepository) CountAlbums(variants []string, filter *models.SearchFilter) (uint64, error) {
+	args := &Args{}
+	cond, _ := albumCondition(args, variants, filter)
+
+	return sr.count("SELECT COUNT(*) FROM albums Al "+
+		"JOIN artists Ar ON Al.artist_id = Ar.id "+
+		"WHERE "+cond,
+		*args)
+}
+
+func (sr *SearchRepository) FindAlbums(variants []string, filter *models.SearchFilter, count uint64, offset uint64) ([]*models.Album, error) {
+	var albums []*models.Album
+
+	args := &Args{}
+	cond, params := albumCondition(args, variants, filter)
+
+	rows, err := sr.db.Query(fmt.Sprintf(
+		"SELECT Al.id, Al.artist_id, Al.name, Al.photo, Al.year, Ar.name FROM albums Al "+
+			"JOIN artists Ar ON Al.artist_id = Ar.id "+
+			"LEFT JOIN (SELECT T.album_id, SUM(C.score) AS score FROM track_charts C "+
+			"JOIN tracks T ON C.track_id = T.id WHERE C.period = 'all' GROUP BY T.album_id) P ON P.album_id = Al.id "+
+			"WHERE %s "+
+			"ORDER BY 0.8 * greatest(%s, 0.9 * %s) + 0.2 * %s + "+
+			"0.2 * coalesce(P.score, 0) / (coalesce(P.score, 0) + 10.0) DESC, Al.id "+
+			"LIMIT %s OFFSET %s",
+		cond,
+		Similarity("Al.name", params),
+		Similarity("Ar.name", params),
+		TextRank("Al.name", params),
+		args.Add(count),
+		args.Add(offset),
+	), *args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		a := &models.Album{}
+
+		if err := rows.Scan(&a.ID, &a.ArtistID, &a.Name, &a.Photo, &a.Year, &a.Artist); err != nil {
+			return nil, err
+		}
+
+		albums = append(albums, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return albums, nil
+}
+
+func (sr *SearchRepository) CountArtists(variants []string) (uint64, error) {
+	args := &Args{}
+	params := args.AddVariants(variants)
+
+	return sr.count("SELECT COUNT(*) FROM artists Ar WHERE "+Match("Ar.name", params, true), *args)
+}
+
+func (sr *SearchRepository) FindArtists(variants []string, count uint64, offset uint64) ([]*models.Artist, error) {
+	var artists []*models.Artist
+
+	args := &Args{}
+	params := args.AddVariants(variants)
+
+	rows, err := sr.db.Query(fmt.Sprintf(
+		"SELECT Ar.id, Ar.name, Ar.photo FROM artists Ar "+
+			"LEFT JOIN (SELECT Al.artist_id, SUM(C.score) AS score FROM track_charts C "+
+			"JOIN tracks T ON C.track_id = T.id JOIN albums Al ON T.album_id = Al.id "+
+			"WHERE C.period = 'all' GROUP BY Al.artist_id) P ON P.artist_id = Ar.id "+
+			"WHERE %s "+
+			"ORDER BY 0.8 * %s + 0.2 * %s + "+
+			"0.2 * coalesce(P.score, 0) / (coalesce(P.score, 0) + 10.0) DESC, Ar.id "+
+			"LIMIT %s OFFSET %s",
+		Match("Ar.name", params, true),
+		Similarity("Ar.name", params),
+		TextRank("Ar.name", params),
+		args.Add(count),
+		args.Add(offset),
+	), *args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		a := &models.Artist{}
+
+		if err := rows.Scan(&a.ID, &a.Name, &a.Photo); err != nil {
+			return nil, err
+		}
+
+		artists = append(artists, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return artists, nil
+}
+
+// notBlocked hides users who blocked the one searching.
+func notBlocked(args *Args, authID uint64) string {
+	return fmt.Sprintf("NOT EXISTS (SELECT 1 FROM blocks B WHERE B.user_id = U.id AND B.blocked_id = %s)", args.Add(authID))
+}
+
+func (sr *SearchRepository) CountUsers(variants []string, authID uint64) (uint64, error) {
+	args := &Args{}
+	params := args.AddVariants(variants)
+
+	return sr.count("SELECT COUNT(*) FROM users U WHERE ("+Match("U.nickname", params, false)+") AND "+
+		notBlocked(args, authID), *args)
+}
+
+func (sr *SearchRepository) FindUsers(variants []string, count uint64, offset uint64, authID uint64) ([]*models.User, error) {
+	var users []*models.User
+
+	args := &Args{}
+	params := args.AddVariants(variants)
+
+	rows, err := sr.db.Query(fmt.Sprintf(
+		"SELECT U.id, U.nickname, U.email, U.avatar, U.role, U.access FROM users U "+
+			"CROSS JOIN LATERAL (SELECT COUNT(*) AS followers FROM subscriptions S "+
+			"WHERE S.subscribed_to = U.id) F "+
+			"WHERE (%s) AND %s "+
+			"ORDER BY 0.8 * %s + 0.2 * F.followers / (F.followers + 10.0) DESC, U.id "+
+			"LIMIT %s OFFSET %s",
+		Match("U.nickname", params, false),
+		notBlocked(args, authID),
+		Similarity("U.nickname", params),
+		args.Add(count),
+		args.Add(offset),
+	), *args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		u := &models.User{}
+
+		if err := rows.Scan(&u.ID, &u.Nickname, &u.Email, &u.Avatar, &u.Role, &u.Access); err != nil {
+			return nil, err
+		}
+
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// prefixArgs binds every variant twice: as a plain prefix for the
+// text_pattern_ops indexes and as a tsquery matching word prefixes.
+func prefixArgs(variants []string) []interface{} {
+	var args []interface{}
+
+	for _, v := range variants {
+		words := strings.FieldsFunc(strings.ToLower(v), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+
+		for i := range words {
+			words[i] += ":*"
+		}
+
+		args = append(args, strings.ToLower(v), strings.Join(words, " & "))
+	}
+
+	return args
+}
+
+func prefixCondition(column string, params []string) string {
+	var conds []string
+
+	for i := 0; i < len(params); i += 2 {
+		conds = append(conds,
+			fmt.Sprintf("%s like %s || '%%'", Fold(column), params[i]),
+			fmt.Sprintf("(%s <> '' AND %s @@ to_tsquery('simple', %s))", params[i+1], Document(column), params[i+1]),
+		)
+	}
+
+	return strings.Join(conds, " OR ")
+}
+
+// prefixRank puts names starting with the query above names where only
+// some word starts with it, shorter names first.
+func prefixRank(column string, params []string) string {
+	var exprs []string
+
+	for i := 0; i < len(params); i += 2 {
+		exprs = append(exprs, fmt.Sprintf("CASE WHEN %s like %s || '%%' THEN 1.0 ELSE 0.5 END", Fold(column), params[i]))
+	}
+
+	return fmt.Sprintf("greatest(%s) - length(%s) / 1000.0", strings.Join(exprs, ", "), column)
+}
+
+func scanSuggestions(rows *sql.Rows) ([]*models.Suggestion, error) {
+	var suggestions []*models.Suggestion
+
+	defer rows.Close()
+
+	for rows.Next() {
+		s := &models.Suggestion{}
+		var rank float64
+
+		if err := rows.Scan(&s.Type, &s.ID, &s.Text, &s.Subtitle, &s.Photo, &rank); err != nil {
+			return nil, err
+		}
+
+		suggestions = append(suggestions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+func (sr *SearchRepository) Suggest(ctx context.Context, variants []string, count uint64) ([]*models.Suggestion, error) {
+	args := prefixArgs(variants)
+	params := Placeholders(1, len(args))
+	limit := len(args) + 1
+
+	rows, err := sr.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT * FROM ("+
+			"(SELECT 'track', T.id, T.name, Ar.name, Al.photo, "+
+			"%s + 0.2 * coalesce(C.score, 0) / (coalesce(C.score, 0) + 10.0) AS rank FROM tracks T "+
+			"JOIN albums Al ON T.album_id = Al.id "+
+			"JOIN artists Ar ON Al.artist_id = Ar.id "+
+			"LEFT JOIN track_charts C ON C.track_id = T.id AND C.period = 'all' "+
+			"WHERE %s ORDER BY rank DESC LIMIT $%d) "+
+			"UNION ALL "+
+			"(SELECT 'album', Al.id, Al.name, Ar.name, Al.photo, %s AS rank FROM albums Al "+
+			"JOIN artists Ar ON Al.artist_id = Ar.id "+
+			"WHERE %s ORDER BY rank DESC LIMIT $%d) "+
+			"UNION ALL "+
+			"(SELECT 'artist', Ar.id, Ar.name, '', Ar.photo, %s AS rank FROM artists Ar "+
+			"WHERE %s ORDER BY rank DESC LIMIT $%d)"+
+			") S ORDER BY rank DESC LIMIT $%d",
+		prefixRank("T.name", params), prefixCondition("T.name", params), limit,
+		prefixRank("Al.name", params), prefixCondition("Al.name", params), limit,
+		prefixRank("Ar.name", params), prefixCondition("Ar.name", params), limit,
+		limit,
+	), append(args, count)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSuggestions(rows)
+}
+
+func (sr *SearchRepository) SuggestUsers(ctx context.Context, variants []string, count uint64, authID uint64) ([]*models.Suggestion, error) {
+	args := prefixArgs(variants)
+	params := Placeholders(1, len(args))
+
+	var conds []string
+
+	for i := 0; i < len(params); i += 2 {
+		conds = append(conds, fmt.Sprintf("%s like %s || '%%'", Fold("U.nickname"), params[i]))
+	}
+
+	rows, err := sr.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT 'user', U.id, U.nickname, '', U.avatar, length(U.nickname) AS rank FROM users U "+
+			"WHERE (%s) AND NOT EXISTS (SELECT 1 FROM blocks B WHERE B.user_id = U.id AND B.blocked_id = $%d) "+
+			"ORDER BY rank, U.nickname LIMIT $%d",
+		strings.Join(conds, " OR "),
+		len(args)+1,
+		len(args)+2,
+	), append(args, authID, count)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSuggestions(rows)
+}
+
+// StoreQuery moves the query to the top of the user's history and
+// keeps only the given number of the most recent ones.
+func (sr *SearchRepository) StoreQuery(userID uint64, query string, keep uint64) error {
+	if _, err := sr.db.Exec("INSERT INTO search_history (user_id, query) VALUES ($1, $2) "+
+		"ON CONFLICT (user_id, query) DO UPDATE SET searched_at = now()",
+		userID,
+		query,
+	); err != nil {
+		return err
+	}
+
+	if _, err := sr.db.Exec("DELETE FROM search_history WHERE user_id = $1 AND id NOT IN "+
+		"(SELECT id FROM search_history WHERE user_id = $1 ORDER BY searched_at DESC LIMIT $2)",
+		userID,
+		keep,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Trends keep no user identities. To tell how many different users searched
+// for a query today, users are remembered as hashes salted with a salt of the
+// day; salts and hashes are dropped by PruneTrends once the day is over, so
+// only the (query, day) aggregate remains.
+
+// CountQuery increments today's counter of the query and, if the user hasn't
+// searched for it today yet, today's number of its distinct users.
+func (sr *SearchRepository) CountQuery(userID uint64, query string) error {
+	tx, err := sr.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO search_trend_salts (day) VALUES (current_date) ON CONFLICT DO NOTHING"); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res, err := tx.Exec("INSERT INTO search_trend_users (query, day, user_hash) "+
+		"SELECT $1, day, md5(salt || $2::text) FROM search_trend_salts WHERE day = current_date "+
+		"ON CONFLICT DO NOTHING",
+		query,
+		userID,
+	)
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	newUsers, err := res.RowsAffected()
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO search_trends (query, users) VALUES ($1, $2) "+
+		"ON CONFLICT (query, day) DO UPDATE SET count = search_trends.count + 1, "+
+		"users = search_trends.users + excluded.users",
+		query,
+		newUsers,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// forgetTrends takes the user out of today's distinct users of every query,
+// older days can't be linked to the user anymore.
+func forgetTrends(tx *sql.Tx, userID uint64) error {
+	_, err := tx.Exec("WITH forgotten AS ("+
+		"DELETE FROM search_trend_users U USING search_trend_salts S "+
+		"WHERE U.day = S.day AND S.day = current_date AND U.user_hash = md5(S.salt || $1::text) "+
+		"RETURNING U.query, U.day) "+
+		"UPDATE search_trends T SET users = T.users - 1 FROM forgotten F "+
+		"WHERE T.query = F.query AND T.day = F.day",
+		userID,
+	)
+
+	return err
+}
+
+// PruneTrends drops the salts and user hashes of past days and the counters
+// of days older than the given time.
+func (sr *SearchRepository) PruneTrends(before time.Time) error {
+	tx, err := sr.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	for _, q := range []string{
+		"DELETE FROM search_trend_users WHERE day < current_date",
+		"DELETE FROM search_trend_salts WHERE day < current_date",
+	} {
+		if _, err := tx.Exec(q); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM search_trends WHERE day < $1", before); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (sr *SearchRepository) FetchHistory(userID uint64, count uint64) ([]*models.SearchQuery, error) {
+	var queries []*models.SearchQuery
+
+	rows, err := sr.db.Query("SELECT id, query, searched_at FROM search_history "+
+		"WHERE user_id = $1 ORDER BY searched_at DESC LIMIT $2",
+		userID,
+		count,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		q := &models.SearchQuery{}
+
+		if err := rows.Scan(&q.ID, &q.Query, &q.SearchedAt); err != nil {
+			return nil, err
+		}
+
+		queries = append(queries, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return queries, nil
+}
+
+func (sr *SearchRepository) DeleteHistoryItem(userID uint64, itemID uint64) error {
+	if err := sr.db.QueryRow("DELETE FROM search_history WHERE id = $1 AND user_id = $2 RETURNING id",
+		itemID,
+		userID,
+	).Scan(&itemID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// ClearHistory also takes the user's searches out of today's trends.
+func (sr *SearchRepository) ClearHistory(userID uint64) error {
+	tx, err := sr.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM search_history WHERE user_id = $1", userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := forgetTrends(tx, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FetchTrending returns queries searched by at least minUsers different
+// users on some day since the given time, most searched first. Days are
+// not summed up, since the same user may search for the query every day.
+func (sr *SearchRepository) FetchTrending(since time.Time, minUsers uint64, count uint64) ([]*models.TrendingQuery, error) {
+	var queries []*models.TrendingQuery
+
+	rows, err := sr.db.Query("SELECT query, SUM(count) AS total FROM search_trends "+
+		"WHERE day >= $1 GROUP BY query HAVING MAX(users) >= $2 "+
+		"ORDER BY total DESC, query LIMIT $3",
+		since,
+		minUsers,
+		count,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		q := &models.TrendingQuery{}
+
+		if err := rows.Scan(&q.Query, &q.Count); err != nil {
+			return nil, err
+		}
+
+		queries = append(queries, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return queries, nil
+}
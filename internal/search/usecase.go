@@ -1,7 +1,16 @@
 package search
 
-import "2019_2_Covenant/internal/models"
+import (
+	"2019_2_Covenant/internal/models"
+	"context"
+)
 
 type Usecase interface {
-	Search(text string, count uint64, authID uint64) ([]*models.Track, []*models.Album, []*models.Artist, error)
+	Search(text string, types []string, filter *models.SearchFilter, count uint64, offset uint64, authID uint64) (*models.SearchResult, error)
+	Suggest(ctx context.Context, text string, users bool, count uint64, authID uint64) ([]*models.Suggestion, error)
+	FetchHistory(userID uint64, count uint64) ([]*models.SearchQuery, error)
+	DeleteHistoryItem(userID uint64, itemID uint64) error
+	ClearHistory(userID uint64) error
+	FetchTrending(count uint64) ([]*models.TrendingQuery, error)
+	PruneTrends() error
 }
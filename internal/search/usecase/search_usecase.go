@@ -1,51 +1,258 @@
 package usecase
 
 import (
-	"2019_2_Covenant/internal/album"
-	"2019_2_Covenant/internal/artist"
 	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/playlist"
 	"2019_2_Covenant/internal/search"
-	"2019_2_Covenant/internal/track"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/tools/translit"
 	. "2019_2_Covenant/tools/vars"
+	"context"
+	"time"
+)
+
+// Suggestions are requested on every keystroke, so a slow answer is useless
+// for the client: it is dropped and an empty list is returned instead.
+const suggestTimeout = 150 * time.Millisecond
+
+const (
+	historySize = 20
+	// Queries searched by fewer different users than this are not shown
+	// as trending, so that a single user's searches never leak to others.
+	trendingMinUsers = 3
+	trendingWindow   = 7 * 24 * time.Hour
 )
 
 type SearchUsecase struct {
-	trackRepo  track.Repository
-	albumRepo  album.Repository
-	artistRepo artist.Repository
+	searchRepo   search.Repository
+	playlistRepo playlist.Repository
+	logger       *logger.LogrusLogger
 }
 
-func NewSearchUsecase(tR track.Repository, alR album.Repository, arR artist.Repository) search.Usecase {
+func NewSearchUsecase(sR search.Repository, pR playlist.Repository, logger *logger.LogrusLogger) search.Usecase {
 	return &SearchUsecase{
-		trackRepo:  tR,
-		albumRepo:  alR,
-		artistRepo: arR,
+		searchRepo:   sR,
+		playlistRepo: pR,
+		logger:       logger,
 	}
 }
 
-func (su *SearchUsecase) Search(text string, count uint64, authID uint64) ([]*models.Track, []*models.Album, []*models.Artist, error) {
-	tracks, err := su.trackRepo.FindLike(text, count, authID)
-	if err != nil {
-		return nil, nil, nil, err
+// Search returns results of the requested types along with their totals
+// as facets. Every filter must apply to every type requested, and further
+// pages (offset > 0) can be requested for a single type only, so each facet
+// is paged independently of the others.
+func (su *SearchUsecase) Search(text string, types []string, filter *models.SearchFilter, count uint64, offset uint64, authID uint64) (*models.SearchResult, error) {
+	variants := translit.Variants(text)
+
+	if variants == nil {
+		return nil, ErrBadParam
+	}
+
+	if filter.Favourites && authID == 0 {
+		return nil, ErrUnathorized
+	}
+
+	if offset > 0 && len(types) > 1 {
+		return nil, ErrBadParam
+	}
+
+	counters := map[string]func() (uint64, error){
+		SEARCH_TRACK: func() (uint64, error) {
+			return su.searchRepo.CountTracks(variants, filter, authID)
+		},
+		SEARCH_ALBUM: func() (uint64, error) {
+			return su.searchRepo.CountAlbums(variants, filter)
+		},
+		SEARCH_ARTIST: func() (uint64, error) {
+			return su.searchRepo.CountArtists(variants)
+		},
+		SEARCH_USER: func() (uint64, error) {
+			return su.searchRepo.CountUsers(variants, authID)
+		},
+		SEARCH_PLAYLIST: func() (uint64, error) {
+			return su.playlistRepo.CountLike(variants, authID)
+		},
+	}
+
+	wanted := map[string]bool{}
+	result := &models.SearchResult{
+		Facets: map[string]uint64{},
+	}
+
+	var found uint64
+	var err error
+
+	for _, t := range types {
+		counter, ok := counters[t]
+
+		if !ok || !filter.AppliesTo(t) {
+			return nil, ErrBadParam
+		}
+
+		if wanted[t] {
+			continue
+		}
+
+		wanted[t] = true
+
+		if result.Facets[t], err = counter(); err != nil {
+			return nil, err
+		}
+
+		found += result.Facets[t]
+	}
+
+	if found == 0 {
+		return nil, ErrNotFound
+	}
+
+	if wanted[SEARCH_TRACK] {
+		if result.Tracks, err = su.searchRepo.FindTracks(variants, filter, count, offset, authID); err != nil {
+			return nil, err
+		}
+
+		if result.Tracks == nil {
+			result.Tracks = []*models.Track{}
+		}
+	}
+
+	if wanted[SEARCH_ALBUM] {
+		if result.Albums, err = su.searchRepo.FindAlbums(variants, filter, count, offset); err != nil {
+			return nil, err
+		}
+
+		if result.Albums == nil {
+			result.Albums = []*models.Album{}
+		}
+	}
+
+	if wanted[SEARCH_ARTIST] {
+		if result.Artists, err = su.searchRepo.FindArtists(variants, count, offset); err != nil {
+			return nil, err
+		}
+
+		if result.Artists == nil {
+			result.Artists = []*models.Artist{}
+		}
+	}
+
+	if wanted[SEARCH_USER] {
+		if result.Users, err = su.searchRepo.FindUsers(variants, count, offset, authID); err != nil {
+			return nil, err
+		}
+
+		if result.Users == nil {
+			result.Users = []*models.User{}
+		}
+	}
+
+	if wanted[SEARCH_PLAYLIST] {
+		if result.Playlists, err = su.playlistRepo.FindLike(variants, authID, count, offset); err != nil {
+			return nil, err
+		}
+
+		if result.Playlists == nil {
+			result.Playlists = []*models.Playlist{}
+		}
 	}
 
-	albums, err := su.albumRepo.FindLike(text, count)
+	if offset == 0 {
+		if len(types) == 1 && types[0] == SEARCH_USER {
+			text = "@" + text
+		}
+
+		su.remember(text, authID)
+	}
+
+	return result, nil
+}
+
+func (su *SearchUsecase) Suggest(ctx context.Context, text string, users bool, count uint64, authID uint64) ([]*models.Suggestion, error) {
+	variants := translit.Variants(text)
+
+	if variants == nil {
+		return nil, ErrBadParam
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, suggestTimeout)
+	defer cancel()
+
+	var suggestions []*models.Suggestion
+	var err error
+
+	if users {
+		suggestions, err = su.searchRepo.SuggestUsers(ctx, variants, count, authID)
+	} else {
+		suggestions, err = su.searchRepo.Suggest(ctx, variants, count)
+	}
+
+	if err != nil && ctx.Err() == nil {
+		return nil, err
+	}
+
+	if suggestions == nil || err != nil {
+		suggestions = []*models.Suggestion{}
+	}
+
+	return suggestions, nil
+}
+
+// remember records the query in the user's history and in the trending
+// counters. Anonymous searches are not counted, since there is no telling
+// whether they come from different users. Failures don't affect the search itself.
+func (su *SearchUsecase) remember(text string, authID uint64) {
+	if authID == 0 {
+		return
+	}
+
+	query := translit.Normalize(text)
+
+	if err := su.searchRepo.StoreQuery(authID, query, historySize); err != nil {
+		su.logger.L.Error("Search history: can't store query: ", err)
+	}
+
+	if err := su.searchRepo.CountQuery(authID, query); err != nil {
+		su.logger.L.Error("Search trends: can't count query: ", err)
+	}
+}
+
+func (su *SearchUsecase) FetchHistory(userID uint64, count uint64) ([]*models.SearchQuery, error) {
+	queries, err := su.searchRepo.FetchHistory(userID, count)
+
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
+	}
+
+	if queries == nil {
+		queries = []*models.SearchQuery{}
 	}
 
-	artists, err := su.artistRepo.FindLike(text, count)
+	return queries, nil
+}
+
+func (su *SearchUsecase) DeleteHistoryItem(userID uint64, itemID uint64) error {
+	return su.searchRepo.DeleteHistoryItem(userID, itemID)
+}
+
+func (su *SearchUsecase) ClearHistory(userID uint64) error {
+	return su.searchRepo.ClearHistory(userID)
+}
+
+func (su *SearchUsecase) FetchTrending(count uint64) ([]*models.TrendingQuery, error) {
+	queries, err := su.searchRepo.FetchTrending(time.Now().Add(-trendingWindow), trendingMinUsers, count)
+
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
 
-	if tracks == nil && albums == nil && artists == nil {
-		return nil, nil, nil, ErrNotFound
+	if queries == nil {
+		queries = []*models.TrendingQuery{}
 	}
 
-	if tracks == nil { tracks = []*models.Track{} }
-	if albums == nil { albums = []*models.Album{} }
-	if artists == nil { artists = []*models.Artist{} }
+	return queries, nil
+}
 
-	return tracks, albums, artists, nil
+// PruneTrends forgets trends that are out of the trending window
+func (su *SearchUsecase) PruneTrends() error {
+	return su.searchRepo.PruneTrends(time.Now().Add(-trendingWindow))
 }
@@ -12,13 +12,13 @@ import (
 	"2019_2_Covenant/tools/vars"
 	"github.com/labstack/echo/v4"
 	"net/http"
-	"time"
+	"strconv"
 )
 
 type SessionHandler struct {
 	BaseHandler
-	SUsecase  session.Usecase
-	UUsecase  user.Usecase
+	SUsecase session.Usecase
+	UUsecase user.Usecase
 }
 
 func NewSessionHandler(sUC session.Usecase,
@@ -38,9 +38,13 @@ func NewSessionHandler(sUC session.Usecase,
 
 func (sh *SessionHandler) Configure(e *echo.Echo) {
 	e.POST("/api/v1/sessions", sh.CreateSession())
-	e.DELETE("/api/v1/sessions", sh.DeleteSession(), sh.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/sessions", sh.DeleteSession(), sh.MManager.CheckAuthStrictly, sh.MManager.DenyTokens)
 
-	e.GET("/api/v1/csrf", sh.GetCSRF(), sh.MManager.CheckAuthStrictly)
+	e.GET("/api/v1/csrf", sh.GetCSRF(), sh.MManager.CheckAuthStrictly, sh.MManager.DenyTokens)
+
+	e.GET("/api/v1/profile/sessions", sh.GetSessions(), sh.MManager.CheckAuthStrictly, sh.MManager.DenyTokens)
+	e.DELETE("/api/v1/profile/sessions", sh.RevokeOtherSessions(), sh.MManager.CheckAuthStrictly, sh.MManager.DenyTokens)
+	e.DELETE("/api/v1/profile/sessions/:id", sh.RevokeSession(), sh.MManager.CheckAuthStrictly, sh.MManager.DenyTokens)
 }
 
 // @Tags Session
@@ -59,6 +63,7 @@ func (sh *SessionHandler) CreateSession() echo.HandlerFunc {
 	type Request struct {
 		Email    string `json:"email" validate:"required,email"`
 		Password string `json:"password" validate:"required"`
+		Remember bool   `json:"remember"`
 	}
 
 	return func(c echo.Context) error {
@@ -87,8 +92,7 @@ func (sh *SessionHandler) CreateSession() echo.HandlerFunc {
 			})
 		}
 
-		sess, cookie := models.NewSession(usr.ID)
-		c.SetCookie(cookie)
+		sess := models.NewSession(usr.ID, c.Request().UserAgent(), c.RealIP(), request.Remember)
 
 		if err = sh.SUsecase.Store(sess); err != nil {
 			sh.Logger.Log(c, "error", "Session store error.", err)
@@ -97,7 +101,9 @@ func (sh *SessionHandler) CreateSession() echo.HandlerFunc {
 			})
 		}
 
-		token, err := models.NewCSRFTokenManager("Covenant").Create(sess.UserID, sess.Data, time.Now().Add(24*time.Hour))
+		c.SetCookie(sess.Cookie())
+
+		token, err := models.NewCSRFTokenManager("Covenant").Create(sess.UserID, sess.Data, sess.Expires)
 		c.Response().Header().Set("X-CSRF-Token", token)
 
 		if err != nil {
@@ -143,13 +149,7 @@ func (sh *SessionHandler) DeleteSession() echo.HandlerFunc {
 			})
 		}
 
-		cookie := &http.Cookie{
-			Name:    "Covenant",
-			Value:   sess.Data,
-			Expires: time.Now().AddDate(0, 0, -1),
-		}
-
-		c.SetCookie(cookie)
+		c.SetCookie(sess.ExpiredCookie())
 
 		return c.JSON(http.StatusOK, Response{
 			Message: "success",
@@ -168,7 +168,7 @@ func (sh *SessionHandler) GetCSRF() echo.HandlerFunc {
 			})
 		}
 
-		token, err := models.NewCSRFTokenManager("Covenant").Create(sess.UserID, sess.Data, time.Now().Add(24*time.Hour))
+		token, err := models.NewCSRFTokenManager("Covenant").Create(sess.UserID, sess.Data, sess.Expires)
 		c.Response().Header().Set("X-CSRF-Token", token)
 
 		if err != nil {
@@ -183,3 +183,99 @@ func (sh *SessionHandler) GetCSRF() echo.HandlerFunc {
 		})
 	}
 }
+
+func (sh *SessionHandler) GetSessions() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			sh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: vars.ErrInternalServerError.Error(),
+			})
+		}
+
+		sessions, err := sh.SUsecase.FetchByUserID(sess.UserID, sess.ID)
+
+		if err != nil {
+			sh.Logger.Log(c, "error", "Error while fetching sessions.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"sessions": sessions,
+			},
+		})
+	}
+}
+
+func (sh *SessionHandler) RevokeSession() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			sh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: vars.ErrInternalServerError.Error(),
+			})
+		}
+
+		sID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			sh.Logger.Log(c, "info", "Atoi error.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: vars.ErrBadParam.Error(),
+			})
+		}
+
+		if err := sh.SUsecase.Revoke(sess.UserID, uint64(sID)); err != nil {
+			if err == vars.ErrNotFound {
+				sh.Logger.Log(c, "info", "Session not found.", sID)
+				return c.JSON(http.StatusNotFound, Response{
+					Error: err.Error(),
+				})
+			}
+
+			sh.Logger.Log(c, "error", "Error while revoking session.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if uint64(sID) == sess.ID {
+			c.SetCookie(sess.ExpiredCookie())
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (sh *SessionHandler) RevokeOtherSessions() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			sh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: vars.ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := sh.SUsecase.RevokeOthers(sess.UserID, sess.ID); err != nil {
+			sh.Logger.Log(c, "error", "Error while revoking sessions.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
@@ -2,6 +2,7 @@ package session
 
 import (
 	"2019_2_Covenant/internal/models"
+	"time"
 )
 
 /*
@@ -12,4 +13,9 @@ type Repository interface {
 	Get(value string) (*models.Session, error)
 	Store(newSession *models.Session) error
 	DeleteByID(id uint64) error
+	Touch(id uint64, ip string, expires time.Time) error
+	DeleteExpired() (int64, error)
+	FetchByUserID(userID uint64) ([]*models.Session, error)
+	Revoke(userID uint64, id uint64) error
+	RevokeOthers(userID uint64, currentID uint64) error
 }
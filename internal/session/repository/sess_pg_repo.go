@@ -21,27 +21,26 @@ func NewSessionRepository(db *sql.DB) session.Repository {
 func (sr *SessionRepository) Get(value string) (*models.Session, error) {
 	item := &models.Session{}
 
-	if err := sr.db.QueryRow("SELECT id, user_id, expires, data FROM sessions WHERE data = $1",
+	if err := sr.db.QueryRow("SELECT id, user_id, expires, deadline, remember, data, user_agent, ip, created_at, last_seen "+
+		"FROM sessions WHERE data = $1",
 		value,
 	).Scan(
 		&item.ID,
 		&item.UserID,
 		&item.Expires,
+		&item.Deadline,
+		&item.Remember,
 		&item.Data,
+		&item.UserAgent,
+		&item.IP,
+		&item.CreatedAt,
+		&item.LastSeen,
 	); err != nil {
 		return nil, err
 	}
 
-	timeNow := time.Now()
-	diffTime := item.Expires.Sub(timeNow)
-
-	if diffTime <= 0 {
-		err := sr.DeleteByID(item.ID)
-
-		if err != nil {
-			return nil, err
-		}
-
+	// Expired sessions are left for the sweep, see DeleteExpired
+	if !item.Expires.After(time.Now()) {
 		return nil, ErrExpired
 	}
 
@@ -49,12 +48,19 @@ func (sr *SessionRepository) Get(value string) (*models.Session, error) {
 }
 
 func (sr *SessionRepository) Store(newSession *models.Session) error {
-	if err := sr.db.QueryRow("INSERT INTO sessions (user_id, expires, data) VALUES ($1, $2, $3) RETURNING id",
+	if err := sr.db.QueryRow("INSERT INTO sessions (user_id, expires, deadline, remember, data, user_agent, ip) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, last_seen",
 		newSession.UserID,
 		newSession.Expires,
+		newSession.Deadline,
+		newSession.Remember,
 		newSession.Data,
+		newSession.UserAgent,
+		newSession.IP,
 	).Scan(
 		&newSession.ID,
+		&newSession.CreatedAt,
+		&newSession.LastSeen,
 	); err != nil {
 		return ErrInternalServerError
 	}
@@ -79,3 +85,85 @@ func (sr *SessionRepository) DeleteByID(id uint64) error {
 
 	return nil
 }
+
+func (sr *SessionRepository) Touch(id uint64, ip string, expires time.Time) error {
+	if _, err := sr.db.Exec("UPDATE sessions SET last_seen = now(), ip = $1, expires = $2 WHERE id = $3",
+		ip,
+		expires,
+		id,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *SessionRepository) FetchByUserID(userID uint64) ([]*models.Session, error) {
+	var sessions []*models.Session
+
+	rows, err := sr.db.Query("SELECT id, user_id, expires, remember, user_agent, ip, created_at, last_seen FROM sessions "+
+		"WHERE user_id = $1 AND expires > now() ORDER BY last_seen DESC",
+		userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		s := &models.Session{}
+
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Expires, &s.Remember, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeen); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Revoke deletes the session only if it belongs to the user.
+func (sr *SessionRepository) Revoke(userID uint64, id uint64) error {
+	res, err := sr.db.Exec("DELETE FROM sessions WHERE id = $1 AND user_id = $2",
+		id,
+		userID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (sr *SessionRepository) RevokeOthers(userID uint64, currentID uint64) error {
+	if _, err := sr.db.Exec("DELETE FROM sessions WHERE user_id = $1 AND id <> $2",
+		userID,
+		currentID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (sr *SessionRepository) DeleteExpired() (int64, error) {
+	res, err := sr.db.Exec("DELETE FROM sessions WHERE expires <= now()")
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -1,9 +1,26 @@
 package session
 
-import "2019_2_Covenant/internal/models"
+import (
+	"2019_2_Covenant/internal/models"
+	"time"
+)
+
+// Timeouts after which sessions expire: Idle since the last use and
+// Absolute since the login. Remembered sessions use the Remember pair.
+type Timeouts struct {
+	Idle             time.Duration
+	Absolute         time.Duration
+	RememberIdle     time.Duration
+	RememberAbsolute time.Duration
+}
 
 type Usecase interface {
 	Get(value string) (*models.Session, error)
 	Store(newSession *models.Session) error
 	DeleteByID(id uint64) error
+	Touch(sess *models.Session, ip string) (bool, error)
+	DeleteExpired() (int64, error)
+	FetchByUserID(userID uint64, currentID uint64) ([]*models.Session, error)
+	Revoke(userID uint64, id uint64) error
+	RevokeOthers(userID uint64, currentID uint64) error
 }
@@ -2,16 +2,28 @@ package usecase
 
 import (
 	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/realtime"
 	"2019_2_Covenant/internal/session"
+	. "2019_2_Covenant/tools/vars"
+	"time"
 )
 
+// Sessions used more often are not touched, not to write on every request
+const touchInterval = time.Minute
+
 type sessionUsecase struct {
-	sessionRepo session.Repository
+	sessionRepo  session.Repository
+	timeouts     session.Timeouts
+	disconnector realtime.Disconnector
 }
 
-func NewSessionUsecase(sr session.Repository) session.Usecase {
+// NewSessionUsecase creates the usecase closing realtime connections
+// of the sessions it ends through the disconnector.
+func NewSessionUsecase(sr session.Repository, timeouts session.Timeouts, d realtime.Disconnector) session.Usecase {
 	return &sessionUsecase{
-		sessionRepo: sr,
+		sessionRepo:  sr,
+		timeouts:     timeouts,
+		disconnector: d,
 	}
 }
 
@@ -26,6 +38,12 @@ func (sUC *sessionUsecase) Get(value string) (*models.Session, error) {
 }
 
 func (sUC *sessionUsecase) Store(newSession *models.Session) error {
+	idle, absolute := sUC.lifetime(newSession)
+	now := time.Now()
+
+	newSession.Deadline = now.Add(absolute)
+	newSession.Expires = earliest(now.Add(idle), newSession.Deadline)
+
 	err := sUC.sessionRepo.Store(newSession)
 
 	if err != nil {
@@ -42,5 +60,103 @@ func (sUC *sessionUsecase) DeleteByID(id uint64) error {
 		return err
 	}
 
+	sUC.disconnector.CloseSession(id)
+
+	return nil
+}
+
+// Touch records the use of the session. If less than half of the idle
+// timeout is left, the session is prolonged (up to its absolute deadline)
+// and true is returned, so that the cookie can be renewed as well.
+func (sUC *sessionUsecase) Touch(sess *models.Session, ip string) (bool, error) {
+	idle, _ := sUC.lifetime(sess)
+	expires := earliest(time.Now().Add(idle), sess.Deadline)
+	renew := time.Until(sess.Expires) < idle/2 && expires.After(sess.Expires)
+
+	if !renew && time.Since(sess.LastSeen) < touchInterval && sess.IP == ip {
+		return false, nil
+	}
+
+	if !renew {
+		expires = sess.Expires
+	}
+
+	if err := sUC.sessionRepo.Touch(sess.ID, ip, expires); err != nil {
+		return false, ErrInternalServerError
+	}
+
+	sess.Expires = expires
+	sess.LastSeen = time.Now()
+	sess.IP = ip
+
+	return renew, nil
+}
+
+func (sUC *sessionUsecase) DeleteExpired() (int64, error) {
+	deleted, err := sUC.sessionRepo.DeleteExpired()
+
+	if err != nil {
+		return 0, ErrInternalServerError
+	}
+
+	return deleted, nil
+}
+
+func (sUC *sessionUsecase) lifetime(sess *models.Session) (time.Duration, time.Duration) {
+	if sess.Remember {
+		return sUC.timeouts.RememberIdle, sUC.timeouts.RememberAbsolute
+	}
+
+	return sUC.timeouts.Idle, sUC.timeouts.Absolute
+}
+
+func earliest(a time.Time, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
+
+// FetchByUserID returns active sessions of the user marking the current one.
+func (sUC *sessionUsecase) FetchByUserID(userID uint64, currentID uint64) ([]*models.Session, error) {
+	sessions, err := sUC.sessionRepo.FetchByUserID(userID)
+
+	if err != nil {
+		return nil, ErrInternalServerError
+	}
+
+	if sessions == nil {
+		sessions = []*models.Session{}
+	}
+
+	for _, s := range sessions {
+		s.Current = s.ID == currentID
+	}
+
+	return sessions, nil
+}
+
+func (sUC *sessionUsecase) Revoke(userID uint64, id uint64) error {
+	if err := sUC.sessionRepo.Revoke(userID, id); err != nil {
+		if err == ErrNotFound {
+			return err
+		}
+
+		return ErrInternalServerError
+	}
+
+	sUC.disconnector.CloseSession(id)
+
+	return nil
+}
+
+func (sUC *sessionUsecase) RevokeOthers(userID uint64, currentID uint64) error {
+	if err := sUC.sessionRepo.RevokeOthers(userID, currentID); err != nil {
+		return ErrInternalServerError
+	}
+
+	sUC.disconnector.CloseOtherSessions(userID, currentID)
+
 	return nil
 }
@@ -1,9 +1,9 @@
 package delivery
 
 import (
-	"2019_2_Covenant/internal/subscriptions"
 	"2019_2_Covenant/internal/middlewares"
 	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/subscriptions"
 	"2019_2_Covenant/internal/user"
 	"2019_2_Covenant/pkg/logger"
 	"2019_2_Covenant/pkg/reader"
@@ -12,6 +12,7 @@ import (
 	. "2019_2_Covenant/tools/vars"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"strconv"
 )
 
 type SubscriptionHandler struct {
@@ -37,8 +38,12 @@ func NewSubscriptionHandler(sUC subscriptions.Usecase,
 
 func (sh *SubscriptionHandler) Configure(e *echo.Echo) {
 	e.GET("/api/v1/subscriptions", sh.GetSubscriptions(), sh.MManager.CheckAuthStrictly)
-	e.POST("/api/v1/subscriptions", sh.Subscribe(), sh.MManager.CheckAuthStrictly)
-	e.DELETE("/api/v1/subscriptions", sh.Unsubscribe(), sh.MManager.CheckAuthStrictly)
+	e.POST("/api/v1/subscriptions", sh.Subscribe(), sh.MManager.CheckAuthStrictly, sh.MManager.DenyTokens,
+		sh.MManager.CheckVerified(RESTRICT_FOLLOW))
+	e.DELETE("/api/v1/subscriptions", sh.Unsubscribe(), sh.MManager.CheckAuthStrictly, sh.MManager.DenyTokens)
+	e.GET("/api/v1/subscriptions/requests", sh.GetRequests(), sh.MManager.CheckAuthStrictly)
+	e.POST("/api/v1/subscriptions/requests/:id", sh.AcceptRequest(), sh.MManager.CheckAuthStrictly, sh.MManager.DenyTokens)
+	e.DELETE("/api/v1/subscriptions/requests/:id", sh.RejectRequest(), sh.MManager.CheckAuthStrictly, sh.MManager.DenyTokens)
 }
 
 func (sh *SubscriptionHandler) GetSubscriptions() echo.HandlerFunc {
@@ -86,9 +91,9 @@ func (sh *SubscriptionHandler) GetSubscriptions() echo.HandlerFunc {
 
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
-				"followers": followers,
+				"followers":       followers,
 				"total_followers": totalFollowers,
-				"following": following,
+				"following":       following,
 				"total_following": totalFollowing,
 			},
 		})
@@ -119,7 +124,16 @@ func (sh *SubscriptionHandler) Subscribe() echo.HandlerFunc {
 			})
 		}
 
-		if err := sh.SUsecase.Subscribe(sess.UserID, request.SubscriptionID); err != nil {
+		pending, err := sh.SUsecase.Subscribe(sess.UserID, request.SubscriptionID)
+
+		if err == ErrPermissionDenied {
+			sh.Logger.Log(c, "info", "Subscription isn't allowed.", err)
+			return c.JSON(http.StatusForbidden, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if err != nil {
 			sh.Logger.Log(c, "info", "Error while subscribing.", err)
 			return c.JSON(http.StatusBadRequest, Response{
 				Error: err.Error(),
@@ -128,6 +142,9 @@ func (sh *SubscriptionHandler) Subscribe() echo.HandlerFunc {
 
 		return c.JSON(http.StatusOK, Response{
 			Message: "success",
+			Body: &Body{
+				"pending": pending,
+			},
 		})
 	}
 }
@@ -168,3 +185,126 @@ func (sh *SubscriptionHandler) Unsubscribe() echo.HandlerFunc {
 		})
 	}
 }
+
+func (sh *SubscriptionHandler) GetRequests() echo.HandlerFunc {
+	type Request struct {
+		Count  uint64 `query:"count" validate:"required"`
+		Offset uint64 `query:"offset"`
+	}
+
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			sh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := sh.ReqReader.Read(c, request, nil); err != nil {
+			sh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		requesters, total, err := sh.SUsecase.FetchRequests(sess.UserID, request.Count, request.Offset)
+
+		if err != nil {
+			sh.Logger.Log(c, "error", "Error while getting follow requests.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"requests": requesters,
+				"total":    total,
+			},
+		})
+	}
+}
+
+func (sh *SubscriptionHandler) AcceptRequest() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			sh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		uID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			sh.Logger.Log(c, "info", "Atoi error.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		if err := sh.SUsecase.AcceptRequest(sess.UserID, uint64(uID)); err != nil {
+			if err == ErrNotFound {
+				sh.Logger.Log(c, "info", "Follow request not found.", uID)
+				return c.JSON(http.StatusNotFound, Response{
+					Error: err.Error(),
+				})
+			}
+
+			sh.Logger.Log(c, "error", "Error while accepting follow request.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (sh *SubscriptionHandler) RejectRequest() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sess, ok := c.Get("session").(*models.Session)
+
+		if !ok {
+			sh.Logger.Log(c, "error", "Can't extract session from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		uID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			sh.Logger.Log(c, "info", "Atoi error.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		if err := sh.SUsecase.RejectRequest(sess.UserID, uint64(uID)); err != nil {
+			if err == ErrNotFound {
+				sh.Logger.Log(c, "info", "Follow request not found.", uID)
+				return c.JSON(http.StatusNotFound, Response{
+					Error: err.Error(),
+				})
+			}
+
+			sh.Logger.Log(c, "error", "Error while rejecting follow request.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
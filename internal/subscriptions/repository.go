@@ -1,6 +1,12 @@
 package subscriptions
 
+import "2019_2_Covenant/internal/models"
+
 type Repository interface {
 	Subscribe(userID uint64, subscriptionID uint64) error
 	Unsubscribe(userID uint64, subscriptionID uint64) error
+	StoreRequest(userID uint64, subscriptionID uint64) error
+	DeleteRequest(userID uint64, subscriptionID uint64) error
+	AcceptRequest(userID uint64, subscriptionID uint64) error
+	FetchRequests(subscriptionID uint64, count uint64, offset uint64) ([]*models.User, uint64, error)
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"2019_2_Covenant/internal/models"
 	"2019_2_Covenant/internal/subscriptions"
 	. "2019_2_Covenant/tools/vars"
 	"database/sql"
@@ -29,7 +30,7 @@ func (ssR *SubscriptionRepository) Subscribe(userID uint64, subscriptionID uint6
 		userID,
 		subscriptionID,
 	); err != nil {
-			return err
+		return err
 	}
 
 	return nil
@@ -51,3 +52,118 @@ func (ssR *SubscriptionRepository) Unsubscribe(userID uint64, subscriptionID uin
 
 	return nil
 }
+
+// StoreRequest creates a pending subscription to a private profile.
+func (ssR *SubscriptionRepository) StoreRequest(userID uint64, subscriptionID uint64) error {
+	var id uint64
+
+	if err := ssR.db.QueryRow("SELECT id FROM subscriptions WHERE user_id = $1 AND subscribed_to = $2 "+
+		"UNION ALL SELECT id FROM follow_requests WHERE user_id = $1 AND requested_to = $2",
+		userID,
+		subscriptionID,
+	).Scan(&id); err == nil {
+		return ErrAlreadyExist
+	}
+
+	if _, err := ssR.db.Exec("INSERT INTO follow_requests (user_id, requested_to) VALUES ($1, $2)",
+		userID,
+		subscriptionID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (ssR *SubscriptionRepository) DeleteRequest(userID uint64, subscriptionID uint64) error {
+	res, err := ssR.db.Exec("DELETE FROM follow_requests WHERE user_id = $1 AND requested_to = $2",
+		userID,
+		subscriptionID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// AcceptRequest turns the pending request into a subscription.
+func (ssR *SubscriptionRepository) AcceptRequest(userID uint64, subscriptionID uint64) error {
+	tx, err := ssR.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	var id uint64
+
+	if err := tx.QueryRow("DELETE FROM follow_requests WHERE user_id = $1 AND requested_to = $2 RETURNING id",
+		userID,
+		subscriptionID,
+	).Scan(&id); err != nil {
+		tx.Rollback()
+
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO subscriptions (user_id, subscribed_to) VALUES ($1, $2) "+
+		"ON CONFLICT (user_id, subscribed_to) DO NOTHING",
+		userID,
+		subscriptionID,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ssR *SubscriptionRepository) FetchRequests(subscriptionID uint64, count uint64, offset uint64) ([]*models.User, uint64, error) {
+	var users []*models.User
+	var total uint64
+
+	if err := ssR.db.QueryRow("SELECT COUNT(*) FROM follow_requests WHERE requested_to = $1",
+		subscriptionID,
+	).Scan(&total); err != nil {
+		return nil, total, err
+	}
+
+	rows, err := ssR.db.Query("SELECT U.id, U.nickname, U.avatar, U.role, U.access FROM users U "+
+		"JOIN follow_requests R ON U.id = R.user_id WHERE R.requested_to = $1 "+
+		"ORDER BY R.created_at DESC LIMIT $2 OFFSET $3",
+		subscriptionID,
+		count,
+		offset,
+	)
+
+	if err != nil {
+		return nil, total, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		u := &models.User{}
+
+		if err := rows.Scan(&u.ID, &u.Nickname, &u.Avatar, &u.Role, &u.Access); err != nil {
+			return nil, total, err
+		}
+
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, total, err
+	}
+
+	return users, total, nil
+}
@@ -1,6 +1,11 @@
 package subscriptions
 
+import "2019_2_Covenant/internal/models"
+
 type Usecase interface {
-	Subscribe(userID uint64, subscriptionID uint64) error
+	Subscribe(userID uint64, subscriptionID uint64) (bool, error)
 	Unsubscribe(userID uint64, subscriptionID uint64) error
+	FetchRequests(userID uint64, count uint64, offset uint64) ([]*models.User, uint64, error)
+	AcceptRequest(userID uint64, requesterID uint64) error
+	RejectRequest(userID uint64, requesterID uint64) error
 }
@@ -1,45 +1,170 @@
 package usecase
 
 import (
+	"2019_2_Covenant/internal/feed"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/notifications"
 	"2019_2_Covenant/internal/subscriptions"
+	"2019_2_Covenant/internal/user"
+	"2019_2_Covenant/pkg/logger"
 	. "2019_2_Covenant/tools/vars"
 )
 
 type SubscriptionUsecase struct {
 	subscriptionRepo subscriptions.Repository
+	userRepo         user.Repository
+	feedRepo         feed.Repository
+	notificationRepo notifications.Repository
+	logger           *logger.LogrusLogger
 }
 
-func NewSubscriptionUsecase(repo subscriptions.Repository) subscriptions.Usecase {
+func NewSubscriptionUsecase(repo subscriptions.Repository,
+	uRepo user.Repository,
+	fRepo feed.Repository,
+	nRepo notifications.Repository,
+	logger *logger.LogrusLogger) subscriptions.Usecase {
 	return &SubscriptionUsecase{
 		subscriptionRepo: repo,
+		userRepo:         uRepo,
+		feedRepo:         fRepo,
+		notificationRepo: nRepo,
+		logger:           logger,
 	}
 }
 
-func (fUc *SubscriptionUsecase) Subscribe(userID uint64, subscriptionID uint64) error {
-	err := fUc.subscriptionRepo.Subscribe(userID, subscriptionID)
+// Subscribe follows a public profile right away, while for a private one
+// it only creates a request waiting for approval; the returned flag tells
+// whether the subscription is pending. Users who don't accept followers
+// at all or blocked one another can't be subscribed to.
+func (fUc *SubscriptionUsecase) Subscribe(userID uint64, subscriptionID uint64) (bool, error) {
+	if userID == subscriptionID {
+		return false, ErrBadParam
+	}
+
+	target, err := fUc.userRepo.GetSettings(subscriptionID)
+
+	if err != nil {
+		return false, ErrNotFound
+	}
+
+	if target.FollowPolicy == FOLLOW_NOBODY {
+		return false, ErrPermissionDenied
+	}
+
+	for _, pair := range [][2]uint64{{userID, subscriptionID}, {subscriptionID, userID}} {
+		blocked, err := fUc.userRepo.IsBlocked(pair[0], pair[1])
+
+		if err != nil {
+			return false, ErrInternalServerError
+		}
+
+		if blocked {
+			return false, ErrPermissionDenied
+		}
+	}
+
+	if target.Access == PROFILE_PRIVATE {
+		err = fUc.subscriptionRepo.StoreRequest(userID, subscriptionID)
+	} else {
+		err = fUc.subscriptionRepo.Subscribe(userID, subscriptionID)
+	}
 
 	if err == ErrAlreadyExist {
-		return err
+		return false, err
 	}
 
 	if err != nil {
-		return ErrNotFound
+		return false, ErrNotFound
 	}
 
-	return nil
+	if target.Access == PROFILE_PRIVATE {
+		fUc.notify(subscriptionID, NOTIFICATION_FOLLOW_REQUEST, userID)
+		return true, nil
+	}
+
+	fUc.recordFollow(userID, subscriptionID)
+	fUc.notify(subscriptionID, NOTIFICATION_FOLLOW, userID)
+
+	return false, nil
 }
 
+// Unsubscribe removes the subscription or cancels the pending request.
 func (fUc *SubscriptionUsecase) Unsubscribe(userID uint64, subscriptionID uint64) error {
 	err := fUc.subscriptionRepo.Unsubscribe(userID, subscriptionID)
 
 	if err == ErrNotFound {
+		if err := fUc.subscriptionRepo.DeleteRequest(userID, subscriptionID); err != nil {
+			return err
+		}
+
+		fUc.dismiss(subscriptionID, NOTIFICATION_FOLLOW_REQUEST, userID)
+
+		return nil
+	}
+
+	if err != nil {
 		return err
 	}
 
+	if err := fUc.feedRepo.Remove(userID, ACTIVITY_FOLLOW, subscriptionID); err != nil {
+		fUc.logger.L.Error(err)
+	}
+
+	fUc.dismiss(subscriptionID, NOTIFICATION_FOLLOW, userID)
+
+	return nil
+}
+
+func (fUc *SubscriptionUsecase) FetchRequests(userID uint64, count uint64, offset uint64) ([]*models.User, uint64, error) {
+	requesters, total, err := fUc.subscriptionRepo.FetchRequests(userID, count, offset)
+
 	if err != nil {
+		return nil, total, err
+	}
+
+	if requesters == nil {
+		requesters = []*models.User{}
+	}
+
+	return requesters, total, nil
+}
+
+func (fUc *SubscriptionUsecase) AcceptRequest(userID uint64, requesterID uint64) error {
+	if err := fUc.subscriptionRepo.AcceptRequest(requesterID, userID); err != nil {
+		return err
+	}
+
+	fUc.recordFollow(requesterID, userID)
+	fUc.dismiss(userID, NOTIFICATION_FOLLOW_REQUEST, requesterID)
+	fUc.notify(requesterID, NOTIFICATION_REQUEST_ACCEPTED, userID)
+
+	return nil
+}
+
+func (fUc *SubscriptionUsecase) RejectRequest(userID uint64, requesterID uint64) error {
+	if err := fUc.subscriptionRepo.DeleteRequest(requesterID, userID); err != nil {
 		return err
 	}
 
+	fUc.dismiss(userID, NOTIFICATION_FOLLOW_REQUEST, requesterID)
+
 	return nil
 }
 
+func (fUc *SubscriptionUsecase) recordFollow(userID uint64, subscriptionID uint64) {
+	if err := fUc.feedRepo.Store(userID, ACTIVITY_FOLLOW, subscriptionID); err != nil {
+		fUc.logger.L.Error(err)
+	}
+}
+
+func (fUc *SubscriptionUsecase) notify(userID uint64, kind string, actorID uint64) {
+	if _, err := fUc.notificationRepo.Store(userID, kind, actorID, 0); err != nil {
+		fUc.logger.L.Error(err)
+	}
+}
+
+func (fUc *SubscriptionUsecase) dismiss(userID uint64, kind string, actorID uint64) {
+	if err := fUc.notificationRepo.Remove(userID, kind, actorID, 0); err != nil {
+		fUc.logger.L.Error(err)
+	}
+}
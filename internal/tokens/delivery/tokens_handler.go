@@ -0,0 +1,161 @@
+package delivery
+
+import (
+	"2019_2_Covenant/internal/middlewares"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/tokens"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/reader"
+	. "2019_2_Covenant/tools/base_handler"
+	. "2019_2_Covenant/tools/response"
+	. "2019_2_Covenant/tools/vars"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+type TokensHandler struct {
+	BaseHandler
+	TUsecase tokens.Usecase
+}
+
+func NewTokensHandler(tUC tokens.Usecase,
+	mManager *middlewares.MiddlewareManager,
+	logger *logger.LogrusLogger) *TokensHandler {
+	return &TokensHandler{
+		BaseHandler: BaseHandler{
+			MManager:  mManager,
+			Logger:    logger,
+			ReqReader: reader.NewReqReader(),
+		},
+		TUsecase: tUC,
+	}
+}
+
+// Tokens are managed with the session cookie only, so that a leaked
+// token can't be used to issue new ones
+func (th *TokensHandler) Configure(e *echo.Echo) {
+	e.GET("/api/v1/profile/tokens", th.GetTokens(), th.MManager.CheckAuthStrictly, th.MManager.DenyTokens)
+	e.POST("/api/v1/profile/tokens", th.CreateToken(), th.MManager.CheckAuthStrictly, th.MManager.DenyTokens)
+	e.DELETE("/api/v1/profile/tokens/:id", th.RevokeToken(), th.MManager.CheckAuthStrictly, th.MManager.DenyTokens)
+}
+
+func (th *TokensHandler) GetTokens() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			th.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		items, err := th.TUsecase.Fetch(usr.ID)
+
+		if err != nil {
+			th.Logger.Log(c, "error", "Error while fetching tokens.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"tokens": items,
+			},
+		})
+	}
+}
+
+func (th *TokensHandler) CreateToken() echo.HandlerFunc {
+	type Request struct {
+		Name  string `json:"name" validate:"required,max=64"`
+		Scope string `json:"scope" validate:"required"`
+	}
+
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			th.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := th.ReqReader.Read(c, request, nil); err != nil {
+			th.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		token, plain, err := th.TUsecase.Create(usr, request.Name, request.Scope)
+
+		if err != nil {
+			status := http.StatusInternalServerError
+
+			switch err {
+			case ErrBadParam:
+				status = http.StatusBadRequest
+			case ErrPermissionDenied:
+				status = http.StatusForbidden
+			}
+
+			th.Logger.Log(c, "info", "Error while creating token.", err)
+			return c.JSON(status, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"token": token,
+				"value": plain,
+			},
+		})
+	}
+}
+
+func (th *TokensHandler) RevokeToken() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			th.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		tID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			th.Logger.Log(c, "info", "Atoi error.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: ErrBadParam.Error(),
+			})
+		}
+
+		if err := th.TUsecase.Revoke(usr.ID, uint64(tID)); err != nil {
+			if err == ErrNotFound {
+				th.Logger.Log(c, "info", "Token not found.", tID)
+				return c.JSON(http.StatusNotFound, Response{
+					Error: err.Error(),
+				})
+			}
+
+			th.Logger.Log(c, "error", "Error while revoking token.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
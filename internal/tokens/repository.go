@@ -0,0 +1,11 @@
+package tokens
+
+import "2019_2_Covenant/internal/models"
+
+type Repository interface {
+	Store(token *models.Token, tokenHash string) error
+	GetByHash(tokenHash string) (*models.Token, error)
+	Fetch(userID uint64) ([]*models.Token, error)
+	Delete(userID uint64, id uint64) error
+	Touch(id uint64) error
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/tokens"
+	. "2019_2_Covenant/tools/vars"
+	"database/sql"
+)
+
+type TokensRepository struct {
+	db *sql.DB
+}
+
+func NewTokensRepository(db *sql.DB) tokens.Repository {
+	return &TokensRepository{
+		db: db,
+	}
+}
+
+func (tR *TokensRepository) Store(token *models.Token, tokenHash string) error {
+	if err := tR.db.QueryRow("INSERT INTO api_tokens (user_id, name, scope, token_hash) VALUES ($1, $2, $3, $4) "+
+		"RETURNING id, created_at",
+		token.UserID,
+		token.Name,
+		token.Scope,
+		tokenHash,
+	).Scan(&token.ID, &token.CreatedAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tR *TokensRepository) GetByHash(tokenHash string) (*models.Token, error) {
+	t := &models.Token{}
+
+	if err := tR.db.QueryRow("SELECT id, user_id, name, scope, last_used, created_at FROM api_tokens WHERE token_hash = $1",
+		tokenHash,
+	).Scan(&t.ID, &t.UserID, &t.Name, &t.Scope, &t.LastUsed, &t.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (tR *TokensRepository) Fetch(userID uint64) ([]*models.Token, error) {
+	var items []*models.Token
+
+	rows, err := tR.db.Query("SELECT id, user_id, name, scope, last_used, created_at FROM api_tokens "+
+		"WHERE user_id = $1 ORDER BY id DESC",
+		userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		t := &models.Token{}
+
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Scope, &t.LastUsed, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		items = append(items, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (tR *TokensRepository) Delete(userID uint64, id uint64) error {
+	res, err := tR.db.Exec("DELETE FROM api_tokens WHERE id = $1 AND user_id = $2",
+		id,
+		userID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (tR *TokensRepository) Touch(id uint64) error {
+	if _, err := tR.db.Exec("UPDATE api_tokens SET last_used = now() WHERE id = $1",
+		id,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package tokens
+
+import "2019_2_Covenant/internal/models"
+
+type Usecase interface {
+	Create(usr *models.User, name string, scope string) (*models.Token, string, error)
+	Authenticate(plain string) (*models.Token, error)
+	Fetch(userID uint64) ([]*models.Token, error)
+	Revoke(userID uint64, id uint64) error
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/tokens"
+	"2019_2_Covenant/pkg/logger"
+	. "2019_2_Covenant/tools/vars"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Prefix of the tokens, makes them easy to recognise e.g. by secret scanners
+const tokenPrefix = "cvt_"
+
+// Tokens used more often don't get last_used updated, not to write on every request
+const touchInterval = time.Minute
+
+type TokensUsecase struct {
+	tokensRepo tokens.Repository
+	logger     *logger.LogrusLogger
+}
+
+func NewTokensUsecase(repo tokens.Repository, logger *logger.LogrusLogger) tokens.Usecase {
+	return &TokensUsecase{
+		tokensRepo: repo,
+		logger:     logger,
+	}
+}
+
+// Create issues a token for the user returning it along with its plain
+// value, which is shown once and never stored.
+func (tUC *TokensUsecase) Create(usr *models.User, name string, scope string) (*models.Token, string, error) {
+	if !models.ValidScope(scope) {
+		return nil, "", ErrBadParam
+	}
+
+	if scope == TOKEN_SCOPE_ADMIN && usr.Role != ADMIN {
+		return nil, "", ErrPermissionDenied
+	}
+
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", ErrInternalServerError
+	}
+
+	plain := tokenPrefix + hex.EncodeToString(b)
+	token := models.NewToken(usr.ID, name, scope)
+
+	if err := tUC.tokensRepo.Store(token, hashToken(plain)); err != nil {
+		return nil, "", ErrInternalServerError
+	}
+
+	return token, plain, nil
+}
+
+func (tUC *TokensUsecase) Authenticate(plain string) (*models.Token, error) {
+	token, err := tUC.tokensRepo.GetByHash(hashToken(plain))
+
+	if err != nil {
+		if err == ErrNotFound {
+			return nil, ErrUnathorized
+		}
+
+		return nil, ErrInternalServerError
+	}
+
+	if token.LastUsed == nil || time.Since(*token.LastUsed) >= touchInterval {
+		if err := tUC.tokensRepo.Touch(token.ID); err != nil {
+			tUC.logger.L.Error(err)
+		}
+	}
+
+	return token, nil
+}
+
+func (tUC *TokensUsecase) Fetch(userID uint64) ([]*models.Token, error) {
+	items, err := tUC.tokensRepo.Fetch(userID)
+
+	if err != nil {
+		return nil, ErrInternalServerError
+	}
+
+	if items == nil {
+		items = []*models.Token{}
+	}
+
+	return items, nil
+}
+
+func (tUC *TokensUsecase) Revoke(userID uint64, id uint64) error {
+	if err := tUC.tokensRepo.Delete(userID, id); err != nil {
+		if err == ErrNotFound {
+			return err
+		}
+
+		return ErrInternalServerError
+	}
+
+	return nil
+}
+
+func hashToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+
+	return hex.EncodeToString(sum[:])
+}
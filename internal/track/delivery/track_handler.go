@@ -1,6 +1,7 @@
 package delivery
 
 import (
+	"2019_2_Covenant/internal/history"
 	"2019_2_Covenant/internal/middlewares"
 	"2019_2_Covenant/internal/models"
 	"2019_2_Covenant/internal/track"
@@ -10,15 +11,22 @@ import (
 	. "2019_2_Covenant/tools/response"
 	. "2019_2_Covenant/tools/vars"
 	"github.com/labstack/echo/v4"
+	"mime"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 type TrackHandler struct {
 	base_handler.BaseHandler
 	TUsecase track.Usecase
+	HUsecase history.Usecase
 }
 
 func NewTrackHandler(tUC track.Usecase,
+	hUC history.Usecase,
 	mManager *middlewares.MiddlewareManager,
 	logger *logger.LogrusLogger) *TrackHandler {
 	return &TrackHandler{
@@ -28,32 +36,38 @@ func NewTrackHandler(tUC track.Usecase,
 			ReqReader: reader.NewReqReader(),
 		},
 		TUsecase: tUC,
+		HUsecase: hUC,
 	}
 }
 
 func (th *TrackHandler) Configure(e *echo.Echo) {
+	write := th.MManager.TokenScope(TOKEN_SCOPE_LIBRARY_WRITE)
+
 	e.GET("/api/v1/tracks/popular", th.GetPopularTracks(), th.MManager.CheckAuth)
+	e.GET("/api/v1/tracks/:id/stream", th.StreamTrack(), th.MManager.CheckAuthStrictly)
 
 	e.GET("/api/v1/tracks/favourite", th.GetFavourites(), th.MManager.CheckAuthStrictly)
-	e.POST("/api/v1/tracks/favourite", th.AddToFavourites(), th.MManager.CheckAuthStrictly)
-	e.DELETE("/api/v1/tracks/favourite", th.RemoveFavourite(), th.MManager.CheckAuthStrictly)
+	e.POST("/api/v1/tracks/favourite", th.AddToFavourites(), write, th.MManager.CheckAuthStrictly)
+	e.DELETE("/api/v1/tracks/favourite", th.RemoveFavourite(), write, th.MManager.CheckAuthStrictly)
 }
 
 // @Tags Track
 // @Summary Get Popular Tracks Route
-// @Description Getting popular tracks
+// @Description Getting popular tracks chart for the period
 // @ID get-popular-tracks
 // @Accept json
 // @Produce json
+// @Param period query string false "Chart period: day, week (default), month or all"
 // @Success 200 object models.Track
 // @Failure 400 object ResponseError
 // @Failure 404 object ResponseError
 // @Failure 500 object ResponseError
-// @Router /api/v1/tracks/popular [post]
+// @Router /api/v1/tracks/popular [get]
 func (th *TrackHandler) GetPopularTracks() echo.HandlerFunc {
 	type Request struct {
 		Count  uint64 `query:"count" validate:"required"`
 		Offset uint64 `query:"offset"`
+		Period string `query:"period" validate:"omitempty,oneof=day week month all"`
 	}
 
 	return func(c echo.Context) error {
@@ -71,7 +85,11 @@ func (th *TrackHandler) GetPopularTracks() echo.HandlerFunc {
 			authID = sess.UserID
 		}
 
-		tracks, total, err := th.TUsecase.FetchPopular(request.Count, request.Offset, authID)
+		if request.Period == "" {
+			request.Period = CHART_WEEK
+		}
+
+		tracks, total, err := th.TUsecase.FetchPopular(request.Period, request.Count, request.Offset, authID)
 
 		if err != nil {
 			th.Logger.Log(c, "error", "Error while fetching tracks.", err)
@@ -83,7 +101,7 @@ func (th *TrackHandler) GetPopularTracks() echo.HandlerFunc {
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
 				"tracks": tracks,
-				"total": total,
+				"total":  total,
 			},
 		})
 	}
@@ -205,3 +223,93 @@ func (th *TrackHandler) GetFavourites() echo.HandlerFunc {
 		})
 	}
 }
+
+// @Tags Track
+// @Summary Stream Track Route
+// @Description Streaming track audio with HTTP range requests support
+// @ID stream-track
+// @Produce audio/mpeg
+// @Param id path int true "Track ID"
+// @Success 200 {file} binary
+// @Success 206 {file} binary
+// @Failure 401 object Response
+// @Failure 404 object Response
+// @Failure 500 object Response
+// @Router /api/v1/tracks/{id}/stream [get]
+func (th *TrackHandler) StreamTrack() echo.HandlerFunc {
+	rootPath, _ := os.Getwd()
+
+	return func(c echo.Context) error {
+		tID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			th.Logger.Log(c, "info", "Atoi error.", err.Error())
+			return c.JSON(http.StatusNotFound, Response{
+				Error: ErrNotFound.Error(),
+			})
+		}
+
+		t, err := th.TUsecase.GetByID(uint64(tID), 0)
+
+		if err == ErrNotFound {
+			th.Logger.Log(c, "info", "Track not found.", tID)
+			return c.JSON(http.StatusNotFound, Response{
+				Error: ErrNotFound.Error(),
+			})
+		}
+
+		if err != nil {
+			th.Logger.Log(c, "error", "Error while getting track.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		file, err := os.Open(filepath.Join(rootPath, t.Path))
+
+		if err != nil {
+			th.Logger.Log(c, "error", "Can't open track file.", err)
+			return c.JSON(http.StatusNotFound, Response{
+				Error: ErrNotFound.Error(),
+			})
+		}
+
+		defer file.Close()
+
+		info, err := file.Stat()
+
+		if err != nil {
+			th.Logger.Log(c, "error", "Can't stat track file.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		// Only a request starting from the beginning of the file is counted as a play,
+		// seeking produces further range requests which must not be recorded
+		if sess, ok := c.Get("session").(*models.Session); ok && isPlaybackStart(c.Request()) {
+			if err := th.HUsecase.Store(sess.UserID, t.ID); err != nil {
+				th.Logger.Log(c, "error", "Error while storing play.", err)
+			}
+		}
+
+		contentType := mime.TypeByExtension(filepath.Ext(t.Path))
+		if contentType == "" {
+			contentType = "audio/mpeg"
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, contentType)
+		c.Response().Header().Set("Accept-Ranges", "bytes")
+
+		// ServeContent handles Range and If-Range headers and answers with 206 on partial requests
+		http.ServeContent(c.Response(), c.Request(), info.Name(), info.ModTime(), file)
+
+		return nil
+	}
+}
+
+func isPlaybackStart(r *http.Request) bool {
+	rangeHeader := r.Header.Get("Range")
+
+	return rangeHeader == "" || strings.HasPrefix(rangeHeader, "bytes=0-")
+}
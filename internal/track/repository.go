@@ -1,9 +1,15 @@
 package track
 
-import "2019_2_Covenant/internal/models"
+import (
+	"2019_2_Covenant/internal/models"
+	"time"
+)
 
 type Repository interface {
 	Fetch(count uint64, offset uint64, authID uint64) ([]*models.Track, uint64, error)
+	GetByID(trackID uint64, authID uint64) (*models.Track, error)
+	FetchPopular(period string, count uint64, offset uint64, authID uint64) ([]*models.Track, uint64, error)
+	RefreshChart(period string, since time.Time) error
 	StoreFavourite(userID uint64, trackID uint64) error
 	RemoveFavourite(userID uint64, trackID uint64) error
 	FetchFavourites(userID uint64, count uint64, offset uint64) ([]*models.Track, uint64, error)
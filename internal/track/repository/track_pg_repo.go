@@ -6,6 +6,7 @@ import (
 	. "2019_2_Covenant/tools/vars"
 	"database/sql"
 	"strings"
+	"time"
 )
 
 type TrackRepository struct {
@@ -27,11 +28,11 @@ func (tr *TrackRepository) Fetch(count uint64, offset uint64, authID uint64) ([]
 	}
 
 	rows, err := tr.db.Query(
-		"SELECT T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, " +
-			"T.id in (select track_id from favourites where user_id = $1) as favourite, " +
-			"T.id in (select track_id from likes where user_id = $1) AS liked FROM tracks T " +
-		"JOIN albums Al ON T.album_id = Al.id " +
-		"JOIN artists Ar ON Al.artist_id = Ar.id LIMIT $2 OFFSET $3",
+		"SELECT T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, "+
+			"T.id in (select track_id from favourites where user_id = $1) as favourite, "+
+			"T.id in (select track_id from likes where user_id = $1) AS liked FROM tracks T "+
+			"JOIN albums Al ON T.album_id = Al.id "+
+			"JOIN artists Ar ON Al.artist_id = Ar.id LIMIT $2 OFFSET $3",
 		authID,
 		count,
 		offset)
@@ -68,6 +69,139 @@ func (tr *TrackRepository) Fetch(count uint64, offset uint64, authID uint64) ([]
 	return tracks, total, nil
 }
 
+func (tr *TrackRepository) FetchPopular(period string, count uint64, offset uint64, authID uint64) ([]*models.Track, uint64, error) {
+	var tracks []*models.Track
+	var total uint64
+
+	if err := tr.db.QueryRow("SELECT COUNT(*) FROM track_charts WHERE period = $1",
+		period,
+	).Scan(&total); err != nil {
+		return nil, total, err
+	}
+
+	rows, err := tr.db.Query(
+		"SELECT T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, "+
+			"T.id in (select track_id from favourites where user_id = $1) as favourite, "+
+			"T.id in (select track_id from likes where user_id = $1) AS liked FROM track_charts C "+
+			"JOIN tracks T ON C.track_id = T.id "+
+			"JOIN albums Al ON T.album_id = Al.id "+
+			"JOIN artists Ar ON Al.artist_id = Ar.id "+
+			"WHERE C.period = $2 ORDER BY C.position LIMIT $3 OFFSET $4",
+		authID,
+		period,
+		count,
+		offset)
+
+	if err != nil {
+		return nil, total, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		t := &models.Track{}
+		isFavourite := new(bool)
+		isLiked := new(bool)
+
+		if err := rows.Scan(&t.ID, &t.AlbumID, &t.ArtistID, &t.Name, &t.Duration,
+			&t.Photo, &t.Artist, &t.Album, &t.Path, isFavourite, isLiked,
+		); err != nil {
+			return nil, total, err
+		}
+
+		if authID != 0 {
+			t.IsFavourite = isFavourite
+			t.IsLiked = isLiked
+		}
+
+		tracks = append(tracks, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, total, err
+	}
+
+	return tracks, total, nil
+}
+
+// RefreshChart replaces the chart snapshot of the period with scores
+// counted from likes, favourites and plays made since the given time,
+// tracks nobody listened to in the period are left out. The snapshot is
+// built in a staging table first, so the old one is swapped out at once.
+func (tr *TrackRepository) RefreshChart(period string, since time.Time) error {
+	tx, err := tr.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("CREATE TEMP TABLE track_charts_staging " +
+		"(LIKE track_charts INCLUDING DEFAULTS) ON COMMIT DROP",
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Only the activity of the window is scanned and aggregated per track
+	if _, err := tx.Exec(
+		"INSERT INTO track_charts_staging (period, position, track_id, score) "+
+			"SELECT $1, row_number() over (ORDER BY S.score DESC, S.track_id), S.track_id, S.score FROM ("+
+			"SELECT track_id, SUM(weight) AS score FROM ("+
+			"SELECT track_id, 3 AS weight FROM likes WHERE created_at >= $2 UNION ALL "+
+			"SELECT track_id, 2 FROM favourites WHERE created_at >= $2 UNION ALL "+
+			"SELECT track_id, 1 FROM plays WHERE played_at >= $2"+
+			") A GROUP BY track_id) S",
+		period,
+		since,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM track_charts WHERE period = $1", period); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO track_charts SELECT * FROM track_charts_staging"); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tr *TrackRepository) GetByID(trackID uint64, authID uint64) (*models.Track, error) {
+	t := &models.Track{}
+	isFavourite := new(bool)
+	isLiked := new(bool)
+
+	if err := tr.db.QueryRow(
+		"SELECT T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, "+
+			"T.id in (select track_id from favourites where user_id = $1) as favourite, "+
+			"T.id in (select track_id from likes where user_id = $1) AS liked FROM tracks T "+
+			"JOIN albums Al ON T.album_id = Al.id "+
+			"JOIN artists Ar ON Al.artist_id = Ar.id WHERE T.id = $2",
+		authID,
+		trackID,
+	).Scan(&t.ID, &t.AlbumID, &t.ArtistID, &t.Name, &t.Duration,
+		&t.Photo, &t.Artist, &t.Album, &t.Path, isFavourite, isLiked,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	if authID != 0 {
+		t.IsFavourite = isFavourite
+		t.IsLiked = isLiked
+	}
+
+	return t, nil
+}
+
 func (tr *TrackRepository) StoreFavourite(userID uint64, trackID uint64) error {
 	var favID uint64
 
@@ -120,12 +254,12 @@ func (tr *TrackRepository) FetchFavourites(userID uint64, count uint64, offset u
 	}
 
 	rows, err := tr.db.Query(
-		"SELECT T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, " +
-			"T.id in (select track_id from likes where user_id = $1) AS liked FROM tracks T " +
-		"JOIN favourites F ON T.id = F.track_id " +
-		"JOIN albums Al ON T.album_id = Al.id " +
-		"JOIN artists Ar ON Al.artist_id = Ar.id " +
-		"WHERE F.user_id = $1 LIMIT $2 OFFSET $3",
+		"SELECT T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, "+
+			"T.id in (select track_id from likes where user_id = $1) AS liked FROM tracks T "+
+			"JOIN favourites F ON T.id = F.track_id "+
+			"JOIN albums Al ON T.album_id = Al.id "+
+			"JOIN artists Ar ON Al.artist_id = Ar.id "+
+			"WHERE F.user_id = $1 LIMIT $2 OFFSET $3",
 		userID,
 		count,
 		offset,
@@ -163,15 +297,15 @@ func (tr *TrackRepository) FindLike(name string, count uint64, authID uint64) ([
 	var tracks []*models.Track
 
 	rows, err := tr.db.Query(
-		"SELECT T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, " +
-			"T.id in (select track_id from favourites where user_id = $1) AS favourite, " +
-			"T.id in (select track_id from likes where user_id = $1) AS liked FROM tracks T " +
-			"JOIN albums Al ON T.album_id = Al.id " +
-			"JOIN artists Ar ON Al.artist_id = Ar.id WHERE lower(T.name) like '%' || $2 || '%' " +
+		"SELECT T.id, T.album_id, Ar.id, T.name, T.duration, Al.photo, Ar.name, Al.name, T.path, "+
+			"T.id in (select track_id from favourites where user_id = $1) AS favourite, "+
+			"T.id in (select track_id from likes where user_id = $1) AS liked FROM tracks T "+
+			"JOIN albums Al ON T.album_id = Al.id "+
+			"JOIN artists Ar ON Al.artist_id = Ar.id WHERE lower(T.name) like '%' || $2 || '%' "+
 			"OR lower(Ar.name) like '%' || $2 || '%' LIMIT $3",
-			authID,
-			strings.ToLower(name),
-			count)
+		authID,
+		strings.ToLower(name),
+		count)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
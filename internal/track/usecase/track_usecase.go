@@ -1,23 +1,44 @@
 package usecase
 
 import (
+	"2019_2_Covenant/internal/feed"
 	"2019_2_Covenant/internal/models"
 	"2019_2_Covenant/internal/track"
+	"2019_2_Covenant/pkg/logger"
 	"2019_2_Covenant/tools/time_parser"
+	. "2019_2_Covenant/tools/vars"
+	"time"
 )
 
+// chartWindows maps chart period to the window of activity it counts,
+// zero window means all-time chart
+var chartWindows = map[string]time.Duration{
+	CHART_DAY:   24 * time.Hour,
+	CHART_WEEK:  7 * 24 * time.Hour,
+	CHART_MONTH: 30 * 24 * time.Hour,
+	CHART_ALL:   0,
+}
+
 type trackUsecase struct {
 	trackRepo track.Repository
+	feedRepo  feed.Repository
+	logger    *logger.LogrusLogger
 }
 
-func NewTrackUsecase(tr track.Repository) track.Usecase {
+func NewTrackUsecase(tr track.Repository, fRepo feed.Repository, logger *logger.LogrusLogger) track.Usecase {
 	return &trackUsecase{
 		trackRepo: tr,
+		feedRepo:  fRepo,
+		logger:    logger,
 	}
 }
 
-func (tUC *trackUsecase) FetchPopular(count uint64, offset uint64, authID uint64) ([]*models.Track, uint64, error) {
-	tracks, total, err := tUC.trackRepo.Fetch(count, offset, authID)
+func (tUC *trackUsecase) FetchPopular(period string, count uint64, offset uint64, authID uint64) ([]*models.Track, uint64, error) {
+	if _, ok := chartWindows[period]; !ok {
+		return nil, 0, ErrBadParam
+	}
+
+	tracks, total, err := tUC.trackRepo.FetchPopular(period, count, offset, authID)
 
 	if err != nil {
 		return nil, total, err
@@ -27,16 +48,51 @@ func (tUC *trackUsecase) FetchPopular(count uint64, offset uint64, authID uint64
 		tracks = []*models.Track{}
 	}
 
-	for _, item := range tracks { item.Duration = time_parser.GetDuration(item.Duration) }
+	for _, item := range tracks {
+		item.Duration = time_parser.GetDuration(item.Duration)
+	}
 
 	return tracks, total, nil
 }
 
+func (tUC *trackUsecase) RefreshCharts() error {
+	now := time.Now()
+
+	for period, window := range chartWindows {
+		since := time.Time{}
+		if window != 0 {
+			since = now.Add(-window)
+		}
+
+		if err := tUC.trackRepo.RefreshChart(period, since); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tUC *trackUsecase) GetByID(trackID uint64, authID uint64) (*models.Track, error) {
+	t, err := tUC.trackRepo.GetByID(trackID, authID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.Duration = time_parser.GetDuration(t.Duration)
+
+	return t, nil
+}
+
 func (tUC *trackUsecase) StoreFavourite(userID uint64, trackID uint64) error {
 	if err := tUC.trackRepo.StoreFavourite(userID, trackID); err != nil {
 		return err
 	}
 
+	if err := tUC.feedRepo.Store(userID, ACTIVITY_FAVOURITE, trackID); err != nil {
+		tUC.logger.L.Error(err)
+	}
+
 	return nil
 }
 
@@ -45,6 +101,10 @@ func (tUC *trackUsecase) RemoveFavourite(userID uint64, trackID uint64) error {
 		return err
 	}
 
+	if err := tUC.feedRepo.Remove(userID, ACTIVITY_FAVOURITE, trackID); err != nil {
+		tUC.logger.L.Error(err)
+	}
+
 	return nil
 }
 
@@ -59,7 +119,9 @@ func (tUC *trackUsecase) FetchFavourites(userID uint64, count uint64, offset uin
 		tracks = []*models.Track{}
 	}
 
-	for _, item := range tracks { item.Duration = time_parser.GetDuration(item.Duration) }
+	for _, item := range tracks {
+		item.Duration = time_parser.GetDuration(item.Duration)
+	}
 
 	return tracks, total, nil
 }
@@ -75,7 +137,9 @@ func (tUC *trackUsecase) FindLike(name string, count uint64, authID uint64) ([]*
 		tracks = []*models.Track{}
 	}
 
-	for _, item := range tracks { item.Duration = time_parser.GetDuration(item.Duration) }
+	for _, item := range tracks {
+		item.Duration = time_parser.GetDuration(item.Duration)
+	}
 
 	return tracks, nil
 }
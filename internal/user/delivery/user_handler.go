@@ -5,7 +5,9 @@ import (
 	"2019_2_Covenant/internal/models"
 	"2019_2_Covenant/internal/playlist"
 	"2019_2_Covenant/internal/session"
+	"2019_2_Covenant/internal/track"
 	"2019_2_Covenant/internal/user"
+	"2019_2_Covenant/internal/verification"
 	"2019_2_Covenant/pkg/logger"
 	"2019_2_Covenant/pkg/reader"
 	"2019_2_Covenant/tools/base_handler"
@@ -19,7 +21,6 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 )
 
 type UserHandler struct {
@@ -27,11 +28,15 @@ type UserHandler struct {
 	UUsecase user.Usecase
 	SUsecase session.Usecase
 	PUsecase playlist.Usecase
+	TUsecase track.Usecase
+	VUsecase verification.Usecase
 }
 
 func NewUserHandler(uUC user.Usecase,
 	sUC session.Usecase,
 	pUC playlist.Usecase,
+	tUC track.Usecase,
+	vUC verification.Usecase,
 	mManager *middlewares.MiddlewareManager,
 	logger *logger.LogrusLogger) *UserHandler {
 	return &UserHandler{
@@ -43,6 +48,8 @@ func NewUserHandler(uUC user.Usecase,
 		UUsecase: uUC,
 		SUsecase: sUC,
 		PUsecase: pUC,
+		TUsecase: tUC,
+		VUsecase: vUC,
 	}
 }
 
@@ -52,11 +59,14 @@ func (uh *UserHandler) Configure(e *echo.Echo) {
 	e.GET("/api/v1/users/:nickname", uh.GetOtherProfile(), uh.MManager.CheckAuthStrictly)
 	e.GET("/api/v1/users/:id/subscriptions", uh.GetUserSubscriptions(), uh.MManager.CheckAuthStrictly)
 	e.GET("/api/v1/users/:id/playlists", uh.GetUserPlaylists(), uh.MManager.CheckAuthStrictly)
+	e.GET("/api/v1/users/:id/favourites", uh.GetUserFavourites(), uh.MManager.CheckAuthStrictly)
 
 	e.GET("/api/v1/profile", uh.GetProfile(), uh.MManager.CheckAuthStrictly)
-	e.PUT("/api/v1/profile", uh.UpdateUser(), uh.MManager.CheckAuthStrictly)
-	e.PUT("/api/v1/profile/password", uh.UpdatePassword(), uh.MManager.CheckAuthStrictly)
-	e.PUT("/api/v1/profile/avatar", uh.UploadAvatar(), uh.MManager.CheckAuthStrictly)
+	e.PUT("/api/v1/profile", uh.UpdateUser(), uh.MManager.CheckAuthStrictly, uh.MManager.DenyTokens)
+	e.PUT("/api/v1/profile/password", uh.UpdatePassword(), uh.MManager.CheckAuthStrictly, uh.MManager.DenyTokens)
+	e.PUT("/api/v1/profile/avatar", uh.UploadAvatar(), uh.MManager.CheckAuthStrictly, uh.MManager.DenyTokens)
+	e.GET("/api/v1/profile/settings", uh.GetSettings(), uh.MManager.CheckAuthStrictly, uh.MManager.DenyTokens)
+	e.PUT("/api/v1/profile/settings", uh.UpdateSettings(), uh.MManager.CheckAuthStrictly, uh.MManager.DenyTokens)
 }
 
 // @Tags User
@@ -103,8 +113,11 @@ func (uh *UserHandler) CreateUser() echo.HandlerFunc {
 			})
 		}
 
-		sess, cookie := models.NewSession(newUser.ID)
-		c.SetCookie(cookie)
+		if err := uh.VUsecase.Send(newUser.ID); err != nil {
+			uh.Logger.Log(c, "error", "Error while sending verification letter.", err)
+		}
+
+		sess := models.NewSession(newUser.ID, c.Request().UserAgent(), c.RealIP(), false)
 
 		if err := uh.SUsecase.Store(sess); err != nil {
 			uh.Logger.Log(c, "error", "Session store error.", err)
@@ -113,7 +126,9 @@ func (uh *UserHandler) CreateUser() echo.HandlerFunc {
 			})
 		}
 
-		token, err := models.NewCSRFTokenManager("Covenant").Create(sess.UserID, sess.Data, time.Now().Add(24*time.Hour))
+		c.SetCookie(sess.Cookie())
+
+		token, err := models.NewCSRFTokenManager("Covenant").Create(sess.UserID, sess.Data, sess.Expires)
 		c.Response().Header().Set("X-CSRF-Token", token)
 
 		if err != nil {
@@ -173,7 +188,8 @@ func (uh *UserHandler) UpdateUser() echo.HandlerFunc {
 			})
 		}
 
-		usr, err := uh.UUsecase.Update(usr.ID, request.Nickname, request.Email)
+		id, oldEmail := usr.ID, usr.Email
+		usr, err := uh.UUsecase.Update(id, request.Nickname, request.Email)
 
 		if err != nil {
 			uh.Logger.Log(c, "info", "Error while updating user data.", err)
@@ -182,6 +198,12 @@ func (uh *UserHandler) UpdateUser() echo.HandlerFunc {
 			})
 		}
 
+		if usr.Email != oldEmail {
+			if err := uh.VUsecase.Send(id); err != nil {
+				uh.Logger.Log(c, "error", "Error while sending verification letter.", err)
+			}
+		}
+
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
 				"user": usr,
@@ -401,6 +423,15 @@ func (uh *UserHandler) GetUserSubscriptions() echo.HandlerFunc {
 	}
 
 	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			uh.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
 		uID, err := strconv.Atoi(c.Param("id"))
 
 		if err != nil {
@@ -419,6 +450,13 @@ func (uh *UserHandler) GetUserSubscriptions() echo.HandlerFunc {
 			})
 		}
 
+		if err := uh.checkView(uh.UUsecase.CanView, uint64(uID), usr); err != nil {
+			uh.Logger.Log(c, "info", "Can't view user's subscriptions.", err)
+			return c.JSON(viewErrorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
 		followers, totalFollowers, err := uh.UUsecase.GetFollowers(uint64(uID), request.Count, request.Offset)
 
 		if err != nil {
@@ -439,9 +477,9 @@ func (uh *UserHandler) GetUserSubscriptions() echo.HandlerFunc {
 
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
-				"followers": followers,
+				"followers":       followers,
 				"total_followers": totalFollowers,
-				"following": following,
+				"following":       following,
 				"total_following": totalFollowing,
 			},
 		})
@@ -455,6 +493,15 @@ func (uh *UserHandler) GetUserPlaylists() echo.HandlerFunc {
 	}
 
 	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			uh.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
 		uID, err := strconv.Atoi(c.Param("id"))
 
 		if err != nil {
@@ -473,7 +520,14 @@ func (uh *UserHandler) GetUserPlaylists() echo.HandlerFunc {
 			})
 		}
 
-		playlists, total, err := uh.PUsecase.Fetch(uint64(uID), request.Count, request.Offset)
+		if err := uh.checkView(uh.UUsecase.CanView, uint64(uID), usr); err != nil {
+			uh.Logger.Log(c, "info", "Can't view user's playlists.", err)
+			return c.JSON(viewErrorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		playlists, total, err := uh.PUsecase.Fetch(uint64(uID), usr, request.Count, request.Offset)
 
 		if err != nil {
 			uh.Logger.Log(c, "error", "Error while getting playlists.", err.Error())
@@ -485,8 +539,206 @@ func (uh *UserHandler) GetUserPlaylists() echo.HandlerFunc {
 		return c.JSON(http.StatusOK, Response{
 			Body: &Body{
 				"playlists": playlists,
-				"total": total,
+				"total":     total,
 			},
 		})
 	}
-}
\ No newline at end of file
+}
+
+func (uh *UserHandler) GetUserFavourites() echo.HandlerFunc {
+	type Request struct {
+		Count  uint64 `query:"count" validate:"required"`
+		Offset uint64 `query:"offset"`
+	}
+
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			uh.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		uID, err := strconv.Atoi(c.Param("id"))
+
+		if err != nil {
+			uh.Logger.Log(c, "error", "Atoi error.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := uh.ReqReader.Read(c, request, nil); err != nil {
+			uh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if err := uh.checkView(uh.UUsecase.CanViewFavourites, uint64(uID), usr); err != nil {
+			uh.Logger.Log(c, "info", "Can't view user's favourites.", err)
+			return c.JSON(viewErrorStatus(err), Response{
+				Error: err.Error(),
+			})
+		}
+
+		tracks, total, err := uh.TUsecase.FetchFavourites(uint64(uID), request.Count, request.Offset)
+
+		if err != nil {
+			uh.Logger.Log(c, "error", "Error while getting favourites.", err.Error())
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if usr.ID != uint64(uID) {
+			for _, t := range tracks {
+				t.IsFavourite = nil
+				t.IsLiked = nil
+			}
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"tracks": tracks,
+				"total":  total,
+			},
+		})
+	}
+}
+
+func (uh *UserHandler) GetSettings() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			uh.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		settings, err := uh.UUsecase.GetSettings(usr.ID)
+
+		if err != nil {
+			uh.Logger.Log(c, "error", "Error while getting settings.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"settings": settings,
+			},
+		})
+	}
+}
+
+// UpdateSettings changes only the fields present in the request.
+func (uh *UserHandler) UpdateSettings() echo.HandlerFunc {
+	type Request struct {
+		Access               *int8 `json:"access" validate:"omitempty,min=0,max=1"`
+		ShowFavourites       *bool `json:"show_favourites"`
+		ShowHistory          *bool `json:"show_history"`
+		FollowPolicy         *int8 `json:"follow_policy" validate:"omitempty,min=0,max=1"`
+		NotifyFollowers      *bool `json:"notify_followers"`
+		NotifyCollaborations *bool `json:"notify_collaborations"`
+		NotifyLikes          *bool `json:"notify_likes"`
+	}
+
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			uh.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		request := &Request{}
+
+		if err := uh.ReqReader.Read(c, request, nil); err != nil {
+			uh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		settings, err := uh.UUsecase.GetSettings(usr.ID)
+
+		if err != nil {
+			uh.Logger.Log(c, "error", "Error while getting settings.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if request.Access != nil {
+			settings.Access = *request.Access
+		}
+		if request.ShowFavourites != nil {
+			settings.ShowFavourites = *request.ShowFavourites
+		}
+		if request.ShowHistory != nil {
+			settings.ShowHistory = *request.ShowHistory
+		}
+		if request.FollowPolicy != nil {
+			settings.FollowPolicy = *request.FollowPolicy
+		}
+		if request.NotifyFollowers != nil {
+			settings.NotifyFollowers = *request.NotifyFollowers
+		}
+		if request.NotifyCollaborations != nil {
+			settings.NotifyCollaborations = *request.NotifyCollaborations
+		}
+		if request.NotifyLikes != nil {
+			settings.NotifyLikes = *request.NotifyLikes
+		}
+
+		if err := uh.UUsecase.UpdateSettings(usr.ID, settings); err != nil {
+			uh.Logger.Log(c, "error", "Error while updating settings.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Body: &Body{
+				"settings": settings,
+			},
+		})
+	}
+}
+
+// checkView returns ErrPermissionDenied if the viewer isn't allowed to see
+// the part of the user's profile guarded by can.
+func (uh *UserHandler) checkView(can func(uint64, *models.User) (bool, error), id uint64, viewer *models.User) error {
+	allowed, err := can(id, viewer)
+
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
+
+func viewErrorStatus(err error) int {
+	switch err {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrPermissionDenied:
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
+}
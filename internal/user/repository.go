@@ -19,5 +19,8 @@ type Repository interface {
 	Update(id uint64, nickname string, email string) (*models.User, error)
 	GetFollowers(id uint64, count uint64, offset uint64) ([]*models.User, uint64, error)
 	GetFollowing(id uint64, count uint64, offset uint64) ([]*models.User, uint64, error)
-	FindLike(name string, count uint64) ([]*models.User, error)
+	IsFollower(id uint64, followerID uint64) (bool, error)
+	IsBlocked(blockerID uint64, userID uint64) (bool, error)
+	GetSettings(id uint64) (*models.Settings, error)
+	UpdateSettings(id uint64, settings *models.Settings) ([]uint64, error)
 }
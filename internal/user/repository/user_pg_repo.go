@@ -6,7 +6,6 @@ import (
 	. "2019_2_Covenant/tools/vars"
 	"database/sql"
 	"github.com/sirupsen/logrus"
-	"strings"
 )
 
 type UserRepository struct {
@@ -36,9 +35,9 @@ func (ur *UserRepository) Store(newUser *models.User) error {
 func (ur *UserRepository) GetByEmail(email string) (*models.User, error) {
 	u := &models.User{}
 
-	if err := ur.db.QueryRow("SELECT id, nickname, email, avatar, password, role, access FROM users WHERE email = $1",
+	if err := ur.db.QueryRow("SELECT id, nickname, email, avatar, password, role, access, verified FROM users WHERE email = $1",
 		email,
-	).Scan(&u.ID, &u.Nickname, &u.Email, &u.Avatar, &u.Password, &u.Role, &u.Access); err != nil {
+	).Scan(&u.ID, &u.Nickname, &u.Email, &u.Avatar, &u.Password, &u.Role, &u.Access, &u.Verified); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
 		}
@@ -52,9 +51,9 @@ func (ur *UserRepository) GetByEmail(email string) (*models.User, error) {
 func (ur *UserRepository) GetByID(usrID uint64) (*models.User, error) {
 	u := &models.User{}
 
-	if err := ur.db.QueryRow("SELECT id, nickname, email, avatar, password, role, access FROM users WHERE id = $1",
+	if err := ur.db.QueryRow("SELECT id, nickname, email, avatar, password, role, access, verified FROM users WHERE id = $1",
 		usrID,
-	).Scan(&u.ID, &u.Nickname, &u.Email, &u.Avatar, &u.Password, &u.Role, &u.Access); err != nil {
+	).Scan(&u.ID, &u.Nickname, &u.Email, &u.Avatar, &u.Password, &u.Role, &u.Access, &u.Verified); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
 		}
@@ -68,13 +67,16 @@ func (ur *UserRepository) GetByID(usrID uint64) (*models.User, error) {
 func (ur *UserRepository) GetByNickname(nickname string, authID uint64) (*models.User, error) {
 	u := &models.User{}
 	s := new(bool)
+	r := new(bool)
 
-	if err := ur.db.QueryRow("SELECT id, nickname, email, avatar, role, access, " +
-		"id in (select subscribed_to from subscriptions where user_id=$1)" +
-		"FROM users WHERE nickname = $2",
+	if err := ur.db.QueryRow("SELECT id, nickname, email, avatar, role, access, verified, "+
+		"id in (select subscribed_to from subscriptions where user_id=$1), "+
+		"id in (select requested_to from follow_requests where user_id=$1) "+
+		"FROM users WHERE nickname = $2 "+
+		"AND NOT EXISTS (select 1 from blocks where user_id = users.id and blocked_id = $1)",
 		authID,
 		nickname,
-	).Scan(&u.ID, &u.Nickname, &u.Email, &u.Avatar, &u.Role, &u.Access, s); err != nil {
+	).Scan(&u.ID, &u.Nickname, &u.Email, &u.Avatar, &u.Role, &u.Access, &u.Verified, s, r); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
 		}
@@ -84,46 +86,12 @@ func (ur *UserRepository) GetByNickname(nickname string, authID uint64) (*models
 
 	if authID != 0 {
 		u.Subscription = s
+		u.Requested = r
 	}
 
 	return u, nil
 }
 
-func (ur *UserRepository) FindLike(name string, count uint64) ([]*models.User, error) {
-	var users []*models.User
-
-	rows, err := ur.db.Query("SELECT id, nickname, email, avatar, role, access " +
-		"FROM users WHERE lower(nickname) like '%' || $1 || '%' LIMIT $2" ,
-		strings.ToLower(name), count,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-
-		return nil, err
-	}
-
-	defer rows.Close()
-
-	for rows.Next() {
-		u := &models.User{}
-
-		if err := rows.Scan(&u.ID, &u.Nickname, &u.Email, &u.Avatar, &u.Role, &u.Access); err != nil {
-			return nil, err
-		}
-
-		users = append(users, u)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return users, nil
-}
-
 func (ur *UserRepository) Fetch(count uint64) ([]*models.User, error) {
 	var users []*models.User
 
@@ -182,21 +150,41 @@ func (ur *UserRepository) emailExists(email string) (bool, error) {
 	return false, nil
 }
 
+// UpdatePassword also revokes the user's API tokens, which could have
+// been issued by whoever knew the old password.
 func (ur *UserRepository) UpdatePassword(id uint64, password string) error {
-	if _, err := ur.db.Exec("UPDATE users SET password = $1 WHERE id = $2",
+	tx, err := ur.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE users SET password = $1 WHERE id = $2",
 		password,
 		id,
 	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM api_tokens WHERE user_id = $1",
+		id,
+	); err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
+// Update drops the verified flag if the email changes, the new one has to be verified again.
 func (ur *UserRepository) Update(id uint64, nickname string, email string) (*models.User, error) {
 	u := &models.User{}
 
-	if err := ur.db.QueryRow("UPDATE users SET nickname = $1, email = $2 WHERE id = $3 RETURNING nickname, email, avatar",
+	if err := ur.db.QueryRow("UPDATE users SET nickname = $1, email = $2, "+
+		"verified = verified AND email = $2, "+
+		"verification_sent_at = CASE WHEN email = $2 THEN verification_sent_at END "+
+		"WHERE id = $3 RETURNING nickname, email, avatar, verified",
 		nickname,
 		email,
 		id,
@@ -204,6 +192,7 @@ func (ur *UserRepository) Update(id uint64, nickname string, email string) (*mod
 		&u.Nickname,
 		&u.Email,
 		&u.Avatar,
+		&u.Verified,
 	); err != nil {
 		return nil, err
 	}
@@ -221,8 +210,8 @@ func (ur *UserRepository) GetFollowers(id uint64, count uint64, offset uint64) (
 		return nil, total, err
 	}
 
-	rows, err := ur.db.Query("SELECT U.id, U.nickname, U.email, U.avatar, U.role, U.access FROM users U " +
-		"JOIN subscriptions S ON U.id=S.user_id WHERE S.subscribed_to=$1 " +
+	rows, err := ur.db.Query("SELECT U.id, U.nickname, U.email, U.avatar, U.role, U.access FROM users U "+
+		"JOIN subscriptions S ON U.id=S.user_id WHERE S.subscribed_to=$1 "+
 		"ORDER BY U.nickname LIMIT $2 OFFSET $3", id, count, offset)
 
 	if err != nil {
@@ -258,8 +247,8 @@ func (ur *UserRepository) GetFollowing(id uint64, count uint64, offset uint64) (
 		return nil, total, err
 	}
 
-	rows, err := ur.db.Query("SELECT U.id, U.nickname, U.email, U.avatar, U.role, U.access FROM users U " +
-		"JOIN subscriptions S ON U.id=S.subscribed_to WHERE S.user_id=$1 " +
+	rows, err := ur.db.Query("SELECT U.id, U.nickname, U.email, U.avatar, U.role, U.access FROM users U "+
+		"JOIN subscriptions S ON U.id=S.subscribed_to WHERE S.user_id=$1 "+
 		"ORDER BY U.nickname LIMIT $2 OFFSET $3", id, count, offset)
 
 	if err != nil {
@@ -284,3 +273,130 @@ func (ur *UserRepository) GetFollowing(id uint64, count uint64, offset uint64) (
 
 	return users, total, nil
 }
+
+func (ur *UserRepository) IsFollower(id uint64, followerID uint64) (bool, error) {
+	var exists bool
+
+	if err := ur.db.QueryRow("SELECT EXISTS (SELECT 1 FROM subscriptions WHERE user_id = $1 AND subscribed_to = $2)",
+		followerID,
+		id,
+	).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (ur *UserRepository) GetSettings(id uint64) (*models.Settings, error) {
+	s := &models.Settings{}
+
+	if err := ur.db.QueryRow("SELECT U.access, coalesce(S.show_favourites, true), coalesce(S.show_history, false), "+
+		"coalesce(S.follow_policy, $2), coalesce(S.notify_followers, true), "+
+		"coalesce(S.notify_collaborations, true), coalesce(S.notify_likes, true) "+
+		"FROM users U LEFT JOIN user_settings S ON S.user_id = U.id WHERE U.id = $1",
+		id,
+		FOLLOW_EVERYONE,
+	).Scan(
+		&s.Access,
+		&s.ShowFavourites,
+		&s.ShowHistory,
+		&s.FollowPolicy,
+		&s.NotifyFollowers,
+		&s.NotifyCollaborations,
+		&s.NotifyLikes,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// UpdateSettings stores the settings. A profile becoming public accepts
+// all pending follow requests, ids of the requesters who became followers
+// are returned.
+func (ur *UserRepository) UpdateSettings(id uint64, s *models.Settings) ([]uint64, error) {
+	tx, err := ur.db.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("UPDATE users SET access = $1 WHERE id = $2",
+		s.Access,
+		id,
+	); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := tx.Exec("INSERT INTO user_settings (user_id, show_favourites, show_history, follow_policy, "+
+		"notify_followers, notify_collaborations, notify_likes) VALUES ($1, $2, $3, $4, $5, $6, $7) "+
+		"ON CONFLICT (user_id) DO UPDATE SET show_favourites = $2, show_history = $3, follow_policy = $4, "+
+		"notify_followers = $5, notify_collaborations = $6, notify_likes = $7, updated_at = now()",
+		id,
+		s.ShowFavourites,
+		s.ShowHistory,
+		s.FollowPolicy,
+		s.NotifyFollowers,
+		s.NotifyCollaborations,
+		s.NotifyLikes,
+	); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var accepted []uint64
+
+	if s.Access == PROFILE_PUBLIC {
+		rows, err := tx.Query("WITH requests AS ("+
+			"DELETE FROM follow_requests WHERE requested_to = $1 RETURNING user_id) "+
+			"INSERT INTO subscriptions (user_id, subscribed_to) SELECT user_id, $1 FROM requests "+
+			"ON CONFLICT (user_id, subscribed_to) DO NOTHING RETURNING user_id",
+			id,
+		)
+
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		for rows.Next() {
+			var requesterID uint64
+
+			if err := rows.Scan(&requesterID); err != nil {
+				rows.Close()
+				tx.Rollback()
+				return nil, err
+			}
+
+			accepted = append(accepted, requesterID)
+		}
+
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	return accepted, tx.Commit()
+}
+
+// IsBlocked reports whether the user was blocked by the blocker.
+func (ur *UserRepository) IsBlocked(blockerID uint64, userID uint64) (bool, error) {
+	var exists bool
+
+	if err := ur.db.QueryRow("SELECT EXISTS (SELECT 1 FROM blocks WHERE user_id = $1 AND blocked_id = $2)",
+		blockerID,
+		userID,
+	).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
@@ -1,18 +1,30 @@
 package usecase
 
 import (
+	"2019_2_Covenant/internal/feed"
 	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/notifications"
 	"2019_2_Covenant/internal/user"
+	"2019_2_Covenant/pkg/logger"
 	. "2019_2_Covenant/tools/vars"
 )
 
 type userUsecase struct {
-	userRepo user.Repository
+	userRepo         user.Repository
+	feedRepo         feed.Repository
+	notificationRepo notifications.Repository
+	logger           *logger.LogrusLogger
 }
 
-func NewUserUsecase(ur user.Repository) user.Usecase {
+func NewUserUsecase(ur user.Repository,
+	fRepo feed.Repository,
+	nRepo notifications.Repository,
+	logger *logger.LogrusLogger) user.Usecase {
 	return &userUsecase{
-		userRepo: ur,
+		userRepo:         ur,
+		feedRepo:         fRepo,
+		notificationRepo: nRepo,
+		logger:           logger,
 	}
 }
 
@@ -134,16 +146,85 @@ func (uUC *userUsecase) GetFollowers(id uint64, count uint64, offset uint64) ([]
 	return followers, total, nil
 }
 
-func (uUC *userUsecase) FindLike(name string, count uint64) ([]*models.User, error) {
-	users, err := uUC.userRepo.FindLike(name, count)
+// CanView reports whether the viewer may see the user's playlists,
+// favourites and subscriptions: private profiles show them only to
+// followers, the owner and admins, and users blocked by the owner never see them.
+func (uUC *userUsecase) CanView(id uint64, viewer *models.User) (bool, error) {
+	return uUC.canView(id, viewer, nil)
+}
+
+// CanViewFavourites additionally respects the "show favourites" setting.
+func (uUC *userUsecase) CanViewFavourites(id uint64, viewer *models.User) (bool, error) {
+	return uUC.canView(id, viewer, func(s *models.Settings) bool {
+		return s.ShowFavourites
+	})
+}
+
+// CanViewHistory additionally respects the "show history" setting.
+func (uUC *userUsecase) CanViewHistory(id uint64, viewer *models.User) (bool, error) {
+	return uUC.canView(id, viewer, func(s *models.Settings) bool {
+		return s.ShowHistory
+	})
+}
+
+func (uUC *userUsecase) canView(id uint64, viewer *models.User, shown func(*models.Settings) bool) (bool, error) {
+	if viewer != nil && (viewer.ID == id || viewer.Role == ADMIN) {
+		return true, nil
+	}
+
+	if viewer != nil {
+		if blocked, err := uUC.userRepo.IsBlocked(id, viewer.ID); err != nil || blocked {
+			return false, err
+		}
+	}
+
+	settings, err := uUC.userRepo.GetSettings(id)
 
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	if users == nil {
-		users = []*models.User{}
+	if shown != nil && !shown(settings) {
+		return false, nil
 	}
 
-	return users, nil
+	if settings.Access != PROFILE_PRIVATE {
+		return true, nil
+	}
+
+	if viewer == nil {
+		return false, nil
+	}
+
+	return uUC.userRepo.IsFollower(id, viewer.ID)
+}
+
+func (uUC *userUsecase) GetSettings(id uint64) (*models.Settings, error) {
+	return uUC.userRepo.GetSettings(id)
+}
+
+// UpdateSettings stores the settings. Follow requests accepted by making
+// the profile public are followed up the same way as accepted one by one.
+func (uUC *userUsecase) UpdateSettings(id uint64, settings *models.Settings) error {
+	accepted, err := uUC.userRepo.UpdateSettings(id, settings)
+
+	if err != nil {
+		return err
+	}
+
+	for _, requesterID := range accepted {
+		if err := uUC.feedRepo.Store(requesterID, ACTIVITY_FOLLOW, id); err != nil {
+			uUC.logger.L.Error(err)
+		}
+
+		if err := uUC.notificationRepo.Remove(id, NOTIFICATION_FOLLOW_REQUEST, requesterID, 0); err != nil {
+			uUC.logger.L.Error(err)
+		}
+
+		if _, err := uUC.notificationRepo.Store(requesterID, NOTIFICATION_REQUEST_ACCEPTED, id, 0); err != nil {
+			uUC.logger.L.Error(err)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,107 @@
+package delivery
+
+import (
+	"2019_2_Covenant/internal/middlewares"
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/verification"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/reader"
+	. "2019_2_Covenant/tools/base_handler"
+	. "2019_2_Covenant/tools/response"
+	. "2019_2_Covenant/tools/vars"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type VerificationHandler struct {
+	BaseHandler
+	VUsecase verification.Usecase
+}
+
+func NewVerificationHandler(vUC verification.Usecase,
+	mManager *middlewares.MiddlewareManager,
+	logger *logger.LogrusLogger) *VerificationHandler {
+	return &VerificationHandler{
+		BaseHandler: BaseHandler{
+			MManager:  mManager,
+			Logger:    logger,
+			ReqReader: reader.NewReqReader(),
+		},
+		VUsecase: vUC,
+	}
+}
+
+func (vh *VerificationHandler) Configure(e *echo.Echo) {
+	e.POST("/api/v1/verification", vh.Verify())
+	e.POST("/api/v1/profile/verification", vh.Resend(), vh.MManager.CheckAuthStrictly, vh.MManager.DenyTokens)
+}
+
+func (vh *VerificationHandler) Verify() echo.HandlerFunc {
+	type Request struct {
+		Token string `json:"token" validate:"required"`
+	}
+
+	return func(c echo.Context) error {
+		request := &Request{}
+
+		if err := vh.ReqReader.Read(c, request, nil); err != nil {
+			vh.Logger.Log(c, "info", "Invalid request.", err.Error())
+			return c.JSON(http.StatusBadRequest, Response{
+				Error: err.Error(),
+			})
+		}
+
+		if err := vh.VUsecase.Verify(request.Token); err != nil {
+			if err == ErrBadToken {
+				vh.Logger.Log(c, "info", "Bad verification token.")
+				return c.JSON(http.StatusBadRequest, Response{
+					Error: err.Error(),
+				})
+			}
+
+			vh.Logger.Log(c, "error", "Error while verifying email.", err)
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
+
+func (vh *VerificationHandler) Resend() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		usr, ok := c.Get("user").(*models.User)
+
+		if !ok {
+			vh.Logger.Log(c, "error", "Can't extract user from echo.Context.")
+			return c.JSON(http.StatusInternalServerError, Response{
+				Error: ErrInternalServerError.Error(),
+			})
+		}
+
+		if err := vh.VUsecase.Send(usr.ID); err != nil {
+			status := http.StatusInternalServerError
+
+			switch err {
+			case ErrNotFound:
+				status = http.StatusNotFound
+			case ErrAlreadyVerified:
+				status = http.StatusConflict
+			case ErrTooManyRequests:
+				status = http.StatusTooManyRequests
+			}
+
+			vh.Logger.Log(c, "info", "Error while sending verification letter.", err)
+			return c.JSON(status, Response{
+				Error: err.Error(),
+			})
+		}
+
+		return c.JSON(http.StatusOK, Response{
+			Message: "success",
+		})
+	}
+}
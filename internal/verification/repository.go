@@ -0,0 +1,11 @@
+package verification
+
+import (
+	"2019_2_Covenant/internal/models"
+	"time"
+)
+
+type Repository interface {
+	MarkSent(userID uint64, interval time.Duration) (*models.User, error)
+	Verify(userID uint64, email string) error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"2019_2_Covenant/internal/models"
+	"2019_2_Covenant/internal/verification"
+	. "2019_2_Covenant/tools/vars"
+	"database/sql"
+	"time"
+)
+
+type VerificationRepository struct {
+	db *sql.DB
+}
+
+func NewVerificationRepository(db *sql.DB) verification.Repository {
+	return &VerificationRepository{
+		db: db,
+	}
+}
+
+// MarkSent records that a verification letter is being sent to the user
+// and returns the user to send it to. ErrTooManyRequests is returned if
+// the previous letter was sent less than interval ago.
+func (vR *VerificationRepository) MarkSent(userID uint64, interval time.Duration) (*models.User, error) {
+	u := &models.User{}
+
+	if err := vR.db.QueryRow("UPDATE users SET verification_sent_at = now() "+
+		"WHERE id = $1 AND NOT verified "+
+		"AND (verification_sent_at IS NULL OR verification_sent_at < now() - $2 * interval '1 second') "+
+		"RETURNING id, nickname, email",
+		userID,
+		interval.Seconds(),
+	).Scan(&u.ID, &u.Nickname, &u.Email); err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		return nil, vR.whyNotSent(userID)
+	}
+
+	return u, nil
+}
+
+func (vR *VerificationRepository) whyNotSent(userID uint64) error {
+	var verified bool
+
+	if err := vR.db.QueryRow("SELECT verified FROM users WHERE id = $1",
+		userID,
+	).Scan(&verified); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	if verified {
+		return ErrAlreadyVerified
+	}
+
+	return ErrTooManyRequests
+}
+
+// Verify marks the email as verified if the user still has it.
+func (vR *VerificationRepository) Verify(userID uint64, email string) error {
+	res, err := vR.db.Exec("UPDATE users SET verified = true WHERE id = $1 AND email = $2",
+		userID,
+		email,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrBadToken
+	}
+
+	return nil
+}
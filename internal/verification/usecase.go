@@ -0,0 +1,6 @@
+package verification
+
+type Usecase interface {
+	Send(userID uint64) error
+	Verify(token string) error
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"2019_2_Covenant/internal/user"
+	"2019_2_Covenant/internal/verification"
+	"2019_2_Covenant/pkg/logger"
+	"2019_2_Covenant/pkg/mailer"
+	. "2019_2_Covenant/tools/vars"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type VerificationUsecase struct {
+	verificationRepo verification.Repository
+	userRepo         user.Repository
+	mailer           mailer.Mailer
+	logger           *logger.LogrusLogger
+	secret           []byte
+	verifyURL        string
+	linkTTL          time.Duration
+	resendInterval   time.Duration
+}
+
+// NewVerificationUsecase creates the usecase mailing links of the form
+// verifyURL?token=... signed with secret. Links stay valid for linkTTL
+// and are sent to a user not more often than once per resendInterval.
+func NewVerificationUsecase(repo verification.Repository, uRepo user.Repository, m mailer.Mailer,
+	secret string, verifyURL string, linkTTL time.Duration, resendInterval time.Duration,
+	logger *logger.LogrusLogger) verification.Usecase {
+	return &VerificationUsecase{
+		verificationRepo: repo,
+		userRepo:         uRepo,
+		mailer:           m,
+		logger:           logger,
+		secret:           []byte(secret),
+		verifyURL:        verifyURL,
+		linkTTL:          linkTTL,
+		resendInterval:   resendInterval,
+	}
+}
+
+func (vUC *VerificationUsecase) Send(userID uint64) error {
+	usr, err := vUC.verificationRepo.MarkSent(userID, vUC.resendInterval)
+
+	if err != nil {
+		switch err {
+		case ErrNotFound, ErrAlreadyVerified, ErrTooManyRequests:
+			return err
+		}
+
+		return ErrInternalServerError
+	}
+
+	expires := time.Now().Add(vUC.linkTTL).Unix()
+	token := fmt.Sprintf("%d.%d.%s", usr.ID, expires, vUC.sign(usr.ID, usr.Email, expires))
+
+	body := fmt.Sprintf("Hi, %s!\n\n"+
+		"To confirm the email of your Covenant account follow the link:\n%s?token=%s\n\n"+
+		"The link is valid for %s.\n",
+		usr.Nickname, vUC.verifyURL, token, vUC.linkTTL)
+
+	go func() {
+		if err := vUC.mailer.Send(usr.Email, "Confirm your email", body); err != nil {
+			vUC.logger.L.Error(err)
+		}
+	}()
+
+	return nil
+}
+
+// Verify checks the token and marks the email it was issued for as
+// verified. Tokens issued before the email was changed are rejected.
+func (vUC *VerificationUsecase) Verify(token string) error {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return ErrBadToken
+	}
+
+	userID, err := strconv.ParseUint(parts[0], 10, 64)
+
+	if err != nil {
+		return ErrBadToken
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+
+	if err != nil || expires < time.Now().Unix() {
+		return ErrBadToken
+	}
+
+	usr, err := vUC.userRepo.GetByID(userID)
+
+	if err != nil {
+		if err == ErrNotFound {
+			return ErrBadToken
+		}
+
+		return ErrInternalServerError
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(vUC.sign(usr.ID, usr.Email, expires))) {
+		return ErrBadToken
+	}
+
+	if err := vUC.verificationRepo.Verify(usr.ID, usr.Email); err != nil {
+		if err == ErrBadToken {
+			return err
+		}
+
+		return ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (vUC *VerificationUsecase) sign(userID uint64, email string, expires int64) string {
+	h := hmac.New(sha256.New, vUC.secret)
+	h.Write([]byte(fmt.Sprintf("%d:%s:%d", userID, email, expires)))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,80 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+// SMTPMailer sends plain text letters through an SMTP server,
+// authenticating only if the user is set.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(host string, port string, user string, password string, from string) *SMTPMailer {
+	m := &SMTPMailer{
+		addr: net.JoinHostPort(host, port),
+		from: from,
+	}
+
+	if user != "" {
+		m.auth = smtp.PlainAuth("", user, password, host)
+	}
+
+	return m
+}
+
+func (m *SMTPMailer) Send(to string, subject string, body string) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{to}, message(m.from, to, subject, body))
+}
+
+// LogMailer writes letters to w instead of sending them,
+// for local development and tests.
+type LogMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+func NewLogMailer(w io.Writer, from string) *LogMailer {
+	return &LogMailer{
+		w:    w,
+		from: from,
+	}
+}
+
+func (m *LogMailer) Send(to string, subject string, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "%s\r\n\r\n", message(m.from, to, subject, body))
+
+	return err
+}
+
+func message(from string, to string, subject string, body string) []byte {
+	headers := []string{
+		"From: " + from,
+		"To: " + to,
+		"Subject: " + subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	}
+
+	// SMTP requires CRLF line endings, bare LF is rejected by some servers
+	body = strings.Replace(strings.Replace(body, "\r\n", "\n", -1), "\n", "\r\n", -1)
+
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + body)
+}
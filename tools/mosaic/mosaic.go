@@ -0,0 +1,79 @@
+package mosaic
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+)
+
+const (
+	SIZE = 600
+)
+
+// Compose draws the four images as a 2x2 grid into a square jpeg file
+func Compose(paths [4]string, dest string) error {
+	canvas := image.NewRGBA(image.Rect(0, 0, SIZE, SIZE))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: color.Black}, image.Point{}, draw.Src)
+
+	half := SIZE / 2
+
+	for i, path := range paths {
+		img, err := decode(path)
+
+		if err != nil {
+			return err
+		}
+
+		cell := image.Rect(0, 0, half, half).Add(image.Pt((i%2)*half, (i/2)*half))
+		drawScaled(canvas, cell, img)
+	}
+
+	out, err := os.Create(dest)
+
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	return jpeg.Encode(out, canvas, &jpeg.Options{Quality: 90})
+}
+
+func decode(path string) (image.Image, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+
+	return img, err
+}
+
+// drawScaled fills the cell with the central square of the image using nearest-neighbour scaling
+func drawScaled(dst *image.RGBA, cell image.Rectangle, src image.Image) {
+	b := src.Bounds()
+
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+
+	offsetX := b.Min.X + (b.Dx()-side)/2
+	offsetY := b.Min.Y + (b.Dy()-side)/2
+
+	for y := 0; y < cell.Dy(); y++ {
+		sy := offsetY + y*side/cell.Dy()
+
+		for x := 0; x < cell.Dx(); x++ {
+			sx := offsetX + x*side/cell.Dx()
+			dst.Set(cell.Min.X+x, cell.Min.Y+y, src.At(sx, sy))
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package playlist_format
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	M3U8 = "m3u8"
+	XSPF = "xspf"
+)
+
+var ErrUnknownFormat = errors.New("unknown playlist format")
+
+// Entry is a single track reference of an imported or exported playlist.
+// Duration is in seconds, 0 if unknown.
+type Entry struct {
+	Line     int
+	Raw      string
+	Artist   string
+	Title    string
+	Duration int
+	Location string
+}
+
+type xspfPlaylist struct {
+	XMLName   xml.Name    `xml:"playlist"`
+	Xmlns     string      `xml:"xmlns,attr,omitempty"`
+	Version   string      `xml:"version,attr"`
+	Title     string      `xml:"title,omitempty"`
+	TrackList []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Album    string `xml:"album,omitempty"`
+	Duration int    `xml:"duration,omitempty"` // milliseconds
+}
+
+// Detect guesses the format by file name, falling back to the content.
+func Detect(name string, data []byte) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".m3u", ".m3u8":
+		return M3U8
+	case ".xspf":
+		return XSPF
+	}
+
+	trimmed := bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		return XSPF
+	}
+
+	if bytes.HasPrefix(trimmed, []byte("#EXTM3U")) {
+		return M3U8
+	}
+
+	return ""
+}
+
+func Parse(format string, r io.Reader) ([]Entry, error) {
+	switch format {
+	case M3U8:
+		return ParseM3U8(r)
+	case XSPF:
+		return ParseXSPF(r)
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+func Write(format string, w io.Writer, title string, entries []Entry) error {
+	switch format {
+	case M3U8:
+		return WriteM3U8(w, title, entries)
+	case XSPF:
+		return WriteXSPF(w, title, entries)
+	}
+
+	return ErrUnknownFormat
+}
+
+func ParseM3U8(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var info *Entry
+
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "#EXTINF:") {
+			info = &Entry{Line: line, Raw: text}
+			meta := strings.SplitN(strings.TrimPrefix(text, "#EXTINF:"), ",", 2)
+
+			if d, err := strconv.ParseFloat(strings.Fields(meta[0] + " ")[0], 64); err == nil && d > 0 {
+				info.Duration = int(d)
+			}
+
+			if len(meta) == 2 {
+				info.Artist, info.Title = splitName(meta[1])
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		entry := Entry{Line: line, Raw: text}
+
+		if info != nil {
+			entry = *info
+			entry.Raw += "\n" + text
+		}
+
+		entry.Location = text
+
+		if entry.Title == "" {
+			name := path.Base(strings.Replace(text, "\\", "/", -1))
+
+			if unescaped, err := url.PathUnescape(name); err == nil {
+				name = unescaped
+			}
+
+			entry.Artist, entry.Title = splitName(strings.TrimSuffix(name, path.Ext(name)))
+		}
+
+		entries = append(entries, entry)
+		info = nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func ParseXSPF(r io.Reader) ([]Entry, error) {
+	var p xspfPlaylist
+
+	if err := xml.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(p.TrackList))
+
+	for i, t := range p.TrackList {
+		entry := Entry{
+			Line:     i + 1,
+			Raw:      strings.TrimSpace(strings.Join([]string{t.Creator, t.Title, t.Location}, " ")),
+			Artist:   strings.TrimSpace(t.Creator),
+			Title:    strings.TrimSpace(t.Title),
+			Duration: t.Duration / 1000,
+			Location: strings.TrimSpace(t.Location),
+		}
+
+		if entry.Title == "" && entry.Location != "" {
+			name := path.Base(entry.Location)
+			entry.Artist, entry.Title = splitName(strings.TrimSuffix(name, path.Ext(name)))
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func WriteM3U8(w io.Writer, title string, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "#EXTM3U")
+	fmt.Fprintf(bw, "#PLAYLIST:%s\n", title)
+
+	for _, e := range entries {
+		duration := e.Duration
+
+		if duration == 0 {
+			duration = -1
+		}
+
+		fmt.Fprintf(bw, "#EXTINF:%d,%s - %s\n", duration, e.Artist, e.Title)
+		fmt.Fprintln(bw, e.Location)
+	}
+
+	return bw.Flush()
+}
+
+func WriteXSPF(w io.Writer, title string, entries []Entry) error {
+	p := xspfPlaylist{
+		Xmlns:   "http://xspf.org/ns/0/",
+		Version: "1",
+		Title:   title,
+	}
+
+	for _, e := range entries {
+		p.TrackList = append(p.TrackList, xspfTrack{
+			Location: e.Location,
+			Title:    e.Title,
+			Creator:  e.Artist,
+			Duration: e.Duration * 1000,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	return enc.Encode(p)
+}
+
+// ParseDuration converts "hh:mm:ss" or "mm:ss" to seconds.
+func ParseDuration(val string) int {
+	var seconds int
+
+	for _, part := range strings.Split(val, ":") {
+		n, err := strconv.Atoi(part)
+
+		if err != nil {
+			return 0
+		}
+
+		seconds = seconds*60 + n
+	}
+
+	return seconds
+}
+
+func splitName(name string) (string, string) {
+	parts := strings.SplitN(name, " - ", 2)
+
+	if len(parts) == 2 {
+		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	}
+
+	return "", strings.TrimSpace(name)
+}
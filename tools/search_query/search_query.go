@@ -0,0 +1,85 @@
+package search_query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builders of fuzzy matching SQL. Every query variant is matched by
+// substring, trigram word similarity and optionally full-text search, so
+// all of them can use the pg_trgm and tsvector indexes.
+
+// Placeholders returns positional parameters $first..$first+n-1.
+func Placeholders(first int, n int) []string {
+	var params []string
+
+	for i := 0; i < n; i++ {
+		params = append(params, fmt.Sprintf("$%d", first+i))
+	}
+
+	return params
+}
+
+// Args collects arguments of a query built on the fly and hands out
+// their placeholders.
+type Args []interface{}
+
+func (a *Args) Add(v interface{}) string {
+	*a = append(*a, v)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+func (a *Args) AddVariants(variants []string) []string {
+	var params []string
+
+	for _, v := range variants {
+		params = append(params, a.Add(strings.ToLower(v)))
+	}
+
+	return params
+}
+
+// Fold lowercases the column and folds "ё" into "е" the way queries are
+// normalized, the indexes are built on the same expression.
+func Fold(column string) string {
+	return fmt.Sprintf("translate(lower(%s), 'ё', 'е')", column)
+}
+
+// Document is the full-text search vector of the folded column.
+func Document(column string) string {
+	return fmt.Sprintf("to_tsvector('simple', %s)", Fold(column))
+}
+
+func Match(column string, params []string, fullText bool) string {
+	var conds []string
+
+	for _, p := range params {
+		conds = append(conds, fmt.Sprintf("%s like '%%' || %s || '%%' OR %s <%% %s", Fold(column), p, p, Fold(column)))
+
+		if fullText {
+			conds = append(conds, fmt.Sprintf("%s @@ plainto_tsquery('simple', %s)", Document(column), p))
+		}
+	}
+
+	return "(" + strings.Join(conds, " OR ") + ")"
+}
+
+func Similarity(column string, params []string) string {
+	var exprs []string
+
+	for _, p := range params {
+		exprs = append(exprs, fmt.Sprintf("word_similarity(%s, %s)", p, Fold(column)))
+	}
+
+	return "greatest(" + strings.Join(exprs, ", ") + ")"
+}
+
+func TextRank(column string, params []string) string {
+	var exprs []string
+
+	for _, p := range params {
+		exprs = append(exprs, fmt.Sprintf("ts_rank(%s, plainto_tsquery('simple', %s))", Document(column), p))
+	}
+
+	return "greatest(" + strings.Join(exprs, ", ") + ")"
+}
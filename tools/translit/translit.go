@@ -0,0 +1,139 @@
+package translit
+
+import (
+	"strings"
+)
+
+var cyrToLat = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// Longer sequences go first so that "sch" wins over "sh" and "s".
+var latToCyr = []struct {
+	lat string
+	cyr string
+}{
+	{"shch", "щ"}, {"sch", "щ"},
+	{"zh", "ж"}, {"kh", "х"}, {"ts", "ц"}, {"ch", "ч"}, {"sh", "ш"},
+	{"yu", "ю"}, {"ya", "я"}, {"yo", "ё"}, {"ju", "ю"}, {"ja", "я"}, {"jo", "ё"},
+	{"a", "а"}, {"b", "б"}, {"c", "к"}, {"d", "д"}, {"e", "е"}, {"f", "ф"},
+	{"g", "г"}, {"h", "х"}, {"i", "и"}, {"j", "й"}, {"k", "к"}, {"l", "л"},
+	{"m", "м"}, {"n", "н"}, {"o", "о"}, {"p", "п"}, {"q", "к"}, {"r", "р"},
+	{"s", "с"}, {"t", "т"}, {"u", "у"}, {"v", "в"}, {"w", "в"}, {"x", "кс"},
+	{"y", "й"}, {"z", "з"},
+}
+
+const (
+	qwerty = "`qwertyuiop[]asdfghjkl;'zxcvbnm,."
+	jcuken = "ёйцукенгшщзхъфывапролджэячсмитьбю"
+)
+
+var qwertyToJcuken, jcukenToQwerty = func() (map[rune]rune, map[rune]rune) {
+	q, j := []rune(qwerty), []rune(jcuken)
+	to, from := map[rune]rune{}, map[rune]rune{}
+
+	for i := range q {
+		to[q[i]] = j[i]
+		from[j[i]] = q[i]
+	}
+
+	return to, from
+}()
+
+// Normalize lowercases the query, collapses spaces and folds "ё" into "е".
+// Names are matched folded the same way, see search_query.Fold.
+func Normalize(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	return strings.Replace(s, "ё", "е", -1)
+}
+
+// ToLatin transliterates cyrillic letters, leaving everything else as is.
+func ToLatin(s string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(s) {
+		if lat, ok := cyrToLat[r]; ok {
+			b.WriteString(lat)
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// ToCyrillic transliterates latin letters, leaving everything else as is.
+func ToCyrillic(s string) string {
+	var b strings.Builder
+
+	s = strings.ToLower(s)
+
+	for len(s) > 0 {
+		matched := false
+
+		for _, pair := range latToCyr {
+			if strings.HasPrefix(s, pair.lat) {
+				b.WriteString(pair.cyr)
+				s = s[len(pair.lat):]
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			b.WriteByte(s[0])
+			s = s[1:]
+		}
+	}
+
+	return b.String()
+}
+
+// SwitchLayout retypes the text as if the other keyboard layout (qwerty or
+// jcuken) was active, e.g. "rbyj" becomes "кино" and "лштщ" becomes "kino".
+func SwitchLayout(s string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(s) {
+		if c, ok := qwertyToJcuken[r]; ok {
+			b.WriteRune(c)
+		} else if c, ok := jcukenToQwerty[r]; ok {
+			b.WriteRune(c)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// Variants returns the normalized query followed by its distinct
+// transliterated and layout-switched forms.
+func Variants(s string) []string {
+	s = Normalize(s)
+
+	if s == "" {
+		return nil
+	}
+
+	var variants []string
+	seen := map[string]bool{}
+
+	for _, v := range []string{s, ToLatin(s), ToCyrillic(s), SwitchLayout(s)} {
+		v = Normalize(v)
+
+		if v == "" || seen[v] {
+			continue
+		}
+
+		seen[v] = true
+		variants = append(variants, v)
+	}
+
+	return variants
+}
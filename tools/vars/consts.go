@@ -1,8 +1,62 @@
 package vars
 
 const (
-	AVATARS_PATH        = "/resources/avatars/"
-	TRACKS_PATH         = "/resources/music/"
-	ALBUMS_PHOTOS_PATH  = "/resources/photos/albums/"
-	ARTISTS_PHOTOS_PATH = "/resources/photos/artists/"
+	AVATARS_PATH          = "/resources/avatars/"
+	TRACKS_PATH           = "/resources/music/"
+	ALBUMS_PHOTOS_PATH    = "/resources/photos/albums/"
+	ARTISTS_PHOTOS_PATH   = "/resources/photos/artists/"
+	PLAYLISTS_PHOTOS_PATH = "/resources/photos/playlists/"
+
+	DEFAULT_PLAYLIST_PHOTO = PLAYLISTS_PHOTOS_PATH + "default_playlist.jpg"
+)
+
+const (
+	CHART_DAY   = "day"
+	CHART_WEEK  = "week"
+	CHART_MONTH = "month"
+	CHART_ALL   = "all"
+)
+
+const (
+	SEARCH_TRACK    = "track"
+	SEARCH_ALBUM    = "album"
+	SEARCH_ARTIST   = "artist"
+	SEARCH_USER     = "user"
+	SEARCH_PLAYLIST = "playlist"
+)
+
+const (
+	ACTIVITY_LIKE            = "like"
+	ACTIVITY_FAVOURITE       = "favourite"
+	ACTIVITY_PLAYLIST_CREATE = "playlist_create"
+	ACTIVITY_PLAYLIST_UPDATE = "playlist_update"
+	ACTIVITY_FOLLOW          = "follow"
+)
+
+const (
+	NOTIFICATION_FOLLOW           = "follow"
+	NOTIFICATION_FOLLOW_REQUEST   = "follow_request"
+	NOTIFICATION_REQUEST_ACCEPTED = "request_accepted"
+	NOTIFICATION_COLLABORATION    = "collaboration"
+	NOTIFICATION_LIKE             = "like"
+)
+
+const (
+	EVENT_NOTIFICATION = "notification"
+	EVENT_ACTIVITY     = "activity"
+	EVENT_PLAYLIST     = "playlist"
+)
+
+// Actions which can be forbidden to users with unverified email
+const (
+	RESTRICT_PUBLIC_PLAYLISTS = "public_playlists"
+	RESTRICT_COLLABORATION    = "collaboration"
+	RESTRICT_FOLLOW           = "follow"
+)
+
+// Scopes of API tokens, each one includes the previous
+const (
+	TOKEN_SCOPE_READ_ONLY     = "read-only"
+	TOKEN_SCOPE_LIBRARY_WRITE = "library-write"
+	TOKEN_SCOPE_ADMIN         = "admin"
 )
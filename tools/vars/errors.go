@@ -12,5 +12,9 @@ var (
 	ErrBadCSRF             = errors.New("csrf error")
 	ErrUnathorized         = errors.New("unauthorized")
 	ErrUnprocessableEntity = errors.New("unprocessable entity")
-	ErrPermissionDenied     = errors.New("permission denied")
+	ErrPermissionDenied    = errors.New("permission denied")
+	ErrBadToken            = errors.New("invalid or expired token")
+	ErrAlreadyVerified     = errors.New("email already verified")
+	ErrNotVerified         = errors.New("email not verified")
+	ErrTooManyRequests     = errors.New("too many requests")
 )
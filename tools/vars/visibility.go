@@ -0,0 +1,17 @@
+package vars
+
+const (
+	PLAYLIST_PRIVATE  = 0
+	PLAYLIST_PUBLIC   = 1
+	PLAYLIST_UNLISTED = 2
+)
+
+const (
+	PROFILE_PUBLIC  = 0
+	PROFILE_PRIVATE = 1
+)
+
+const (
+	FOLLOW_EVERYONE = 0
+	FOLLOW_NOBODY   = 1
+)